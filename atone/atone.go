@@ -13,6 +13,7 @@ package atone
 import (
 	"fmt"
 	"log"
+	"unsafe"
 )
 
 // Debug is true if we should print debug statements
@@ -22,11 +23,32 @@ var Debug = false
 type Vec[T any] struct {
 	oldHead []T
 	newTail []T
+
+	// snapshots is true once Snapshot has been called, from then on every change publishes a new view
+	snapshots bool
+	// view is the last published *View[T], it's loaded atomically by the readers
+	view unsafe.Pointer
+	// oldShared is how many elements, counting from the start of the oldHead backing array (which also
+	// contains the newTail once it's being carried), published views can still read
+	oldShared int
+	// newShared is how many elements from the start of the newTail published views can still read
+	newShared int
+
+	// alloc hands out the arrays grow uses, nil means the pool of T (see NewWithAllocator)
+	alloc Allocator[T]
+	// buffers are the arrays from alloc that haven't been given back yet
+	buffers [][]T
+	// dirty are the slots of the elements removed by Clear that haven't been zeroed yet
+	dirty [][]T
 }
 
 // NItemsToMoveOnEachInsert is the number of items we move on each insert, between 4-8 the performance doesn't have much difference
 const NItemsToMoveOnEachInsert = 5
 
+// nItemsToZeroOnEachChange is the number of slots left by Clear that we zero on each change, zeroing is cheaper
+// than moving so it can go faster than carry
+const nItemsToZeroOnEachChange = 4 * NItemsToMoveOnEachInsert
+
 // New returns a new atone Vec
 func New[T any]() *Vec[T] {
 	return &Vec[T]{
@@ -45,10 +67,9 @@ func From[T any](elements []T) *Vec[T] {
 
 // NewWithCapacity is the equivalent of doing make([]T, 0, capacity)
 func NewWithCapacity[T any](capacity uint64) *Vec[T] {
-	return &Vec[T]{
-		newTail: make([]T, 0, capacity),
-		oldHead: nil,
-	}
+	v := &Vec[T]{}
+	v.newTail = v.allocate(int(capacity))
+	return v
 }
 
 // returns the oldHead len
@@ -82,16 +103,28 @@ func (v *Vec[T]) Get(index int) T {
 	return v.newTail[offset]
 }
 
-// GetRef returns FOR SURE a pointer to the element even though it is a stack element like int
+// GetRef returns FOR SURE a pointer to the element even though it is a stack element like int.
+// If the Vec has an Allocator (see NewWithAllocator and NewPooled) the pointer must not be used after a change
+// that can grow or carry the Vec (Push, Append...), the array it points to might be recycled by then.
+// If the Vec has snapshots, writes through the pointer are only published with the next change and the pointer
+// must not be written after it, use Set instead.
 func (v *Vec[T]) GetRef(index int) *T {
 	if index < v.oldLen() {
+		v.ownOld(index)
 		return &v.oldHead[index]
 	}
 
 	offset := index - v.oldLen()
+	v.ownNew(offset)
 	return &v.newTail[offset]
 }
 
+// Set sets the element in the specified index, can panic if it is outofbounds
+func (v *Vec[T]) Set(index int, el T) {
+	*v.GetRef(index) = el
+	v.publish()
+}
+
 // Find02 tries to find not doing a continuous loop
 func (v *Vec[T]) Find02(el T, cb func(element T) bool) int {
 	bigger := v.newTail
@@ -166,7 +199,8 @@ func (v *Vec[T]) FindMultithreaded(el T, cb func(T) bool) int {
 
 // Insert .
 func (v *Vec[T]) Insert(el T) {
-
+	v.detach()
+	defer v.publish()
 	if len(v.newTail) == cap(v.newTail) {
 		v.grow(1)
 		v.Insert(el)
@@ -176,6 +210,7 @@ func (v *Vec[T]) Insert(el T) {
 		els := make([]T, 0, cap(v.newTail)+1)
 		els = append(els, el)
 		v.newTail = append(els, v.newTail...)
+		v.releaseUnused()
 		return
 	}
 	// storage for sufficient elements in the new tail. maybe with a better implementaiton we could jump this
@@ -199,6 +234,9 @@ func (v *Vec[T]) Insert(el T) {
 
 // Swap swaps elements in the structure
 func (v *Vec[T]) Swap(i int, j int) {
+	v.ownIndex(i)
+	v.ownIndex(j)
+	defer v.publish()
 	iIsInOldHead := i < v.oldLen()
 	jIsInOldHead := j < v.oldLen()
 
@@ -212,13 +250,13 @@ func (v *Vec[T]) Swap(i int, j int) {
 		return
 	}
 
+	l := v.oldLen()
 	if !iIsInOldHead {
-		l := v.oldLen()
-		v.oldHead[i], v.newTail[j-l] = v.newTail[j-l], v.oldHead[i]
+		v.newTail[i-l], v.oldHead[j] = v.oldHead[j], v.newTail[i-l]
 		return
 	}
 
-	v.oldHead[j], v.newTail[i] = v.newTail[i], v.oldHead[j]
+	v.oldHead[i], v.newTail[j-l] = v.newTail[j-l], v.oldHead[i]
 }
 
 func reverseSlice[T any](s []T) {
@@ -229,17 +267,26 @@ func reverseSlice[T any](s []T) {
 
 // Reverse inplace the array and empties the old head
 func (v *Vec[T]) Reverse() {
+	v.detach()
+	defer v.publish()
 	reverseSlice(v.newTail)
 	if v.oldHead != nil {
 		for i := range v.oldHead {
 			v.newTail = append(v.newTail, v.oldHead[v.oldLen()-i-1])
 		}
+		// the newTail never overlaps the oldHead, but they might share the array
+		if sameArray(v.oldHead, v.newTail) {
+			v.vacate(v.oldHead)
+		}
 		v.oldHead = nil
+		v.releaseUnused()
 	}
 }
 
 // Reserve the desired size inmemory to let space for nElements, it might reserve more memory than necessary for leaving space for more items for carry()
 func (v *Vec[T]) Reserve(nElements int) {
+	v.detach()
+	defer v.publish()
 	if v.oldLen() > 0 {
 		v.carryAll()
 	}
@@ -268,17 +315,26 @@ func (v *Vec[T]) Shrink(minCapacity int) {
 
 // Truncate only will mantain only the first 'n' elements in the array and the rest will be free'd
 func (v *Vec[T]) Truncate(n int) {
+	v.detach()
+	defer v.publish()
 	if n <= v.oldLen() {
+		tail := v.newTail
 		v.newTail = append(v.newTail[:0], v.oldHead[:n]...)
-		if n == v.oldLen() {
-			v.oldHead = nil
-		} else {
-			v.oldHead = v.oldHead[n:]
+		// the newTail starts after the oldHead if they share the array, so the whole oldHead is free now
+		if sameArray(v.oldHead, v.newTail) {
+			v.vacate(v.oldHead)
 		}
+		if sameArray(tail, v.newTail) {
+			v.vacate(tail[min(n, len(tail)):])
+		}
+		v.oldHead = nil
+		v.oldShared = 0
+		v.releaseUnused()
 		return
 	}
 	maintain := n - v.oldLen()
-	v.newTail = append(v.oldHead, v.newTail[:maintain]...)
+	v.vacate(v.newTail[maintain:])
+	v.newTail = v.newTail[:maintain]
 }
 
 // Len returns the number of elements stored in the array
@@ -291,10 +347,34 @@ func (v *Vec[T]) IsEmpty() bool {
 	return v.Len() == 0
 }
 
-// Clear empties the array
+// Clear empties the array. If T has pointers the slots of the removed elements are zeroed a few on each
+// change after it (so the GC can collect what they pointed to without Clear stalling on a big Vec), meanwhile
+// the Vec only uses the capacity after them.
 func (v *Vec[T]) Clear() {
+	if v.snapshots || !needsZeroing[T]() {
+		v.oldHead = nil
+		v.oldShared = 0
+		v.newTail = v.newTail[:0]
+		v.releaseUnused()
+		v.reclaimFront()
+		v.publish()
+		return
+	}
+	// only the arrays the Vec allocated and keeps using are zeroed, any other is left to its owner or the GC
+	if v.owns(v.newTail) {
+		if v.oldLen() > 0 && sameArray(v.oldHead, v.newTail) {
+			v.dirty = append(v.dirty, v.oldHead)
+		}
+		if len(v.newTail) > 0 {
+			v.dirty = append(v.dirty, v.newTail)
+		}
+		v.newTail = v.newTail[len(v.newTail):]
+	} else {
+		v.newTail = v.newTail[:0]
+	}
 	v.oldHead = nil
-	v.newTail = v.newTail[:0]
+	v.releaseUnused()
+	v.reclaimFront()
 }
 
 // Contains returns true if the element is inside the array
@@ -340,7 +420,7 @@ func (v *Vec[T]) Last() T {
 	}
 	oldLen := v.oldLen()
 	if oldLen > 0 {
-		return v.oldHead[oldLen]
+		return v.oldHead[oldLen-1]
 	}
 	return t
 }
@@ -350,12 +430,19 @@ func (v *Vec[T]) PopFront() T {
 	var t T
 	if v.oldLen() > 0 {
 		popped := v.oldHead[0]
+		v.zeroOld(0)
 		v.oldHead = v.oldHead[1:]
+		v.oldShared = max(v.oldShared-1, 0)
+		v.tidy()
+		v.publish()
 		return popped
 	}
 	if len(v.newTail) > 0 {
 		popped := v.newTail[0]
-		v.newTail = v.newTail[1:]
+		v.zeroNew(0)
+		v.dropNew(1)
+		v.tidy()
+		v.publish()
 		return popped
 	}
 	return t
@@ -366,13 +453,19 @@ func (v *Vec[T]) PopBack() T {
 	var t T
 	if len(v.newTail) > 0 {
 		popped := v.newTail[len(v.newTail)-1]
+		v.zeroNew(len(v.newTail) - 1)
 		v.newTail = v.newTail[:len(v.newTail)-1]
+		v.tidy()
+		v.publish()
 		return popped
 	}
 	oldL := v.oldLen()
 	if oldL > 0 {
 		popped := v.oldHead[oldL-1]
+		v.zeroOld(oldL - 1)
 		v.oldHead = v.oldHead[:oldL-1]
+		v.tidy()
+		v.publish()
 		return popped
 	}
 	return t
@@ -402,12 +495,12 @@ func (v *Vec[T]) Slice(start, end int) []T {
 			newEnd = v.oldLen()
 		}
 		elements = append(elements, v.oldHead[start:newEnd]...)
-		if newEnd > v.oldLen() {
-			elements = append(elements, v.newTail[:newEnd-v.oldLen()]...)
+		if end > v.oldLen() {
+			elements = append(elements, v.newTail[:end-v.oldLen()]...)
 		}
 		return elements
 	}
-	elements = append(elements, v.newTail[start:end]...)
+	elements = append(elements, v.newTail[start-v.oldLen():end-v.oldLen()]...)
 	return elements
 }
 
@@ -428,8 +521,9 @@ func (v *Vec[T]) ForEach(fn func(el T, index int)) {
 			fn(v.oldHead[i], i)
 		}
 	}
+	oldLen := v.oldLen()
 	for i := range v.newTail {
-		fn(v.newTail[i], i)
+		fn(v.newTail[i], oldLen+i)
 	}
 }
 
@@ -441,48 +535,172 @@ func (v *Vec[T]) Push(el T) {
 		return
 	}
 
+	// the slot we are about to write might still be read by a snapshot after a PopBack or a Clear
+	v.ownNew(len(v.newTail))
 	v.newTail = append(v.newTail, el)
 	if v.oldLen() != 0 {
 		v.carry()
 	}
+	v.tidy()
+	v.publish()
+}
+
+// InsertAt inserts el in the specified index shifting the elements after it to the right, panics if index is out of [0, Len()]
+func (v *Vec[T]) InsertAt(index int, el T) {
+	if index < 0 || index > v.Len() {
+		panic("atone: InsertAt index out of range")
+	}
+	// pushing first grows (and carries) the Vec the usual way, then we make room for el
+	v.Push(el)
+	lenOld := v.oldLen()
+	last := len(v.newTail) - 1
+	if index >= lenOld {
+		offset := index - lenOld
+		v.ownNew(offset)
+		copy(v.newTail[offset+1:], v.newTail[offset:last])
+		v.newTail[offset] = el
+	} else {
+		v.ownNew(0)
+		copy(v.newTail[1:], v.newTail[:last])
+		v.newTail[0] = v.oldHead[lenOld-1]
+		v.ownOld(index)
+		copy(v.oldHead[index+1:], v.oldHead[index:lenOld-1])
+		v.oldHead[index] = el
+	}
+	v.publish()
+}
+
+// RemoveAt removes the element in the specified index and returns it, shifting the rest of elements, can panic if it is outofbounds
+func (v *Vec[T]) RemoveAt(index int) T {
+	removed := v.Get(index)
+	lenOld := v.oldLen()
+	if index < lenOld {
+		// shifting the start of the oldHead to the right is cheaper than shifting everything after index
+		v.ownOld(0)
+		copy(v.oldHead[1:index+1], v.oldHead[:index])
+		v.dropFront(1)
+		return removed
+	}
+	offset := index - lenOld
+	v.ownNew(offset)
+	copy(v.newTail[offset:], v.newTail[offset+1:])
+	v.PopBack()
+	return removed
 }
 
 // Append is the equivalent of doing append(elements, toAppend...)
 func (v *Vec[T]) Append(el ...T) {
-	for _, e := range el {
-		v.Push(e)
-	}
+	v.extend(el)
 }
 
 func (v *Vec[T]) carry() {
+	v.carryN(NItemsToMoveOnEachInsert)
+}
+
+// carryN moves the last n elements of the oldHead to the front of the newTail
+func (v *Vec[T]) carryN(n int) {
 	if v.oldLen() == 0 {
 		v.oldHead = nil
+		v.oldShared = 0
 		return
 	}
 	lenOld := v.oldLen()
-	calc := max(lenOld-NItemsToMoveOnEachInsert, 0)
-	v.newTail = append(v.oldHead[calc:], v.newTail...)
+	calc := max(lenOld-n, 0)
+	if v.newTailFollowsOldHead() {
+		// the new tail already lives right after the old head, extending it to the left moves nothing
+		v.newTail = v.oldHead[calc : lenOld+len(v.newTail)]
+		if v.snapshots {
+			v.newShared += lenOld - calc
+		}
+	} else {
+		if v.oldShared > lenOld {
+			// the append below would write over elements that a snapshot can still read
+			v.ownOld(0)
+		}
+		v.newTail = append(v.oldHead[calc:], v.newTail...)
+		// only the elements that were already in the oldHead can be shared
+		v.newShared = max(v.oldShared-calc, 0)
+		// the array the newTail lived in might not be needed anymore
+		v.releaseUnused()
+	}
 	v.oldHead = v.oldHead[:calc]
 	if v.oldLen() == 0 {
 		v.oldHead = nil
+		v.oldShared = 0
 		return
 	}
 
 }
 
+// newTailFollowsOldHead returns true if the newTail starts right where the oldHead ends in the same backing array
+func (v *Vec[T]) newTailFollowsOldHead() bool {
+	lenOld := v.oldLen()
+	if len(v.newTail) == 0 || cap(v.oldHead)-lenOld < len(v.newTail) {
+		return false
+	}
+	return &v.oldHead[:lenOld+1][lenOld] == &v.newTail[0]
+}
+
 func (v *Vec[T]) carryAll() {
-	if v.oldLen() == 0 {
+	v.carryN(v.oldLen())
+}
+
+// extend appends all the elements, carrying NItemsToMoveOnEachInsert elements of the oldHead for each one of them like Push
+func (v *Vec[T]) extend(elements []T) {
+	for len(elements) > 0 {
+		if len(v.newTail) == cap(v.newTail) {
+			v.grow(len(elements))
+		}
+		v.ownNew(len(v.newTail))
+		n := min(cap(v.newTail)-len(v.newTail), len(elements))
+		v.newTail = append(v.newTail, elements[:n]...)
+		elements = elements[n:]
+		if v.oldLen() != 0 {
+			v.carryN(n * NItemsToMoveOnEachInsert)
+		}
+		if len(v.dirty) > 0 {
+			v.scrub(n * nItemsToZeroOnEachChange)
+		}
+	}
+	v.reclaimFront()
+	v.publish()
+}
+
+// dropFront removes the first n elements
+func (v *Vec[T]) dropFront(n int) {
+	if lenOld := v.oldLen(); n < lenOld {
+		v.vacate(v.oldHead[:n])
+		v.oldHead = v.oldHead[n:]
+		v.oldShared = max(v.oldShared-n, 0)
+	} else {
+		n -= lenOld
+		v.vacate(v.oldHead)
+		v.vacate(v.newTail[:n])
 		v.oldHead = nil
-		return
+		v.oldShared = 0
+		v.dropNew(n)
 	}
-	v.newTail = append(v.oldHead[0:], v.newTail...)
-	v.oldHead = nil
+	v.tidy()
+	v.publish()
+}
+
+// dropNew removes the first n elements of the newTail. When it's left empty it keeps pointing to the start of
+// the removed elements so reclaimFront can still tell its array, unless a view can read them.
+func (v *Vec[T]) dropNew(n int) {
+	if n == len(v.newTail) && !v.snapshots {
+		v.newTail = v.newTail[:0]
+	} else {
+		v.newTail = v.newTail[n:]
+	}
+	v.newShared = max(v.newShared-n, 0)
 }
 
 const pushMultiplierOldVector = 2
 
 func (v *Vec[T]) grow(growFactor int) {
-	// assertDebug(v.oldLen() == 0)
+	// the oldHead must be empty before it's replaced (this only happens if something filled the newTail before
+	// the end of the migration, like a big Append)
+	v.carryAll()
 	// Original repo comments
 	// We need to grow the Vec by at least a factor of (R + 1)/R to ensure that
 	// the new Vec won't _also_ grow while we're still moving items from the old
@@ -503,11 +721,14 @@ func (v *Vec[T]) grow(growFactor int) {
 	// We also need to make sure we can fit the additional capacity required for `extra`.
 	// Normally, that'll be handled by `pushes`, but not always!
 	add := max(pushes, growFactor)
-	elements := make([]T, 0, cap(v.newTail)+add+pushes+need)
-	v.oldHead = make([]T, 0, add+pushes+need*pushMultiplierOldVector)
+	elements := v.allocate(cap(v.newTail) + add + pushes + need)
+	v.oldHead = v.allocate(add + pushes + need*pushMultiplierOldVector)
 	v.oldHead = append(v.oldHead, v.newTail...)
 
 	v.newTail = elements
+	v.oldShared = 0
+	v.newShared = 0
+	v.releaseUnused()
 }
 
 func max(n, n2 int) int {
@@ -517,6 +738,13 @@ func max(n, n2 int) int {
 	return n2
 }
 
+func min(n, n2 int) int {
+	if n < n2 {
+		return n
+	}
+	return n2
+}
+
 func assertDebug(cond bool) {
 	if !Debug || cond {
 		return
@@ -0,0 +1,410 @@
+package atone
+
+import (
+	"math/bits"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Allocator hands out and takes back the backing arrays of a Vec, see NewWithAllocator
+type Allocator[T any] interface {
+	// Alloc returns a slice with length 0 and a capacity of at least capacity elements
+	Alloc(capacity int) []T
+	// Free takes back a slice returned by Alloc once the Vec doesn't use it anymore, it can still have the
+	// elements the Vec left in it
+	Free(elements []T)
+}
+
+// NewWithAllocator returns a new atone Vec whose storage comes from alloc. Every array that grow allocates is
+// given back to alloc as soon as the migration doesn't need it (or on Vec.Free). Arrays that the Vec didn't get
+// from alloc (like the slice passed to From) are never given to it. A Vec with snapshots doesn't give
+// anything back, a View can read any array it has published.
+//
+// Vecs created any other way allocate with make and leave the arrays they retire to the GC.
+func NewWithAllocator[T any](alloc Allocator[T]) *Vec[T] {
+	return &Vec[T]{
+		newTail: make([]T, 0, 0),
+		alloc:   alloc,
+	}
+}
+
+// NewPooled returns a new atone Vec that recycles its arrays through a PoolAllocator shared by every pooled Vec
+// of T, so Vecs that grow and get cleared all the time reuse the arrays retired by the others. Pointers returned
+// by GetRef must not be used after a change that can grow the Vec, the array might be in use by another Vec.
+func NewPooled[T any]() *Vec[T] {
+	return NewWithAllocator[T](bufferPool[T]())
+}
+
+// bufferPools has the *PoolAllocator[T] of each type T, for NewPooled
+var bufferPools sync.Map
+
+// bufferPool returns the PoolAllocator shared by the pooled Vecs of T
+func bufferPool[T any]() *PoolAllocator[T] {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if pool, ok := bufferPools.Load(t); ok {
+		return pool.(*PoolAllocator[T])
+	}
+	pool, _ := bufferPools.LoadOrStore(t, NewPoolAllocator[T]())
+	return pool.(*PoolAllocator[T])
+}
+
+// allocate returns an empty slice with room for capacity elements from the Allocator of the Vec, or from make
+// if it doesn't have one
+func (v *Vec[T]) allocate(capacity int) []T {
+	var elements []T
+	if v.alloc == nil {
+		elements = make([]T, 0, capacity)
+	} else {
+		elements = v.alloc.Alloc(capacity)[:0]
+	}
+	if cap(elements) > 0 {
+		v.buffers = append(v.buffers, elements)
+	}
+	return elements
+}
+
+// owns returns true if elements live in an array the Vec allocated, the arrays it got from elsewhere (like
+// the slice passed to From) are never zeroed nor given to the Allocator
+func (v *Vec[T]) owns(elements []T) bool {
+	for _, buffer := range v.buffers {
+		if sameArray(buffer, elements) {
+			return true
+		}
+	}
+	return false
+}
+
+// inUse returns true if the oldHead or the newTail live in the array of elements
+func (v *Vec[T]) inUse(elements []T) bool {
+	return sameArray(elements, v.oldHead) || sameArray(elements, v.newTail)
+}
+
+// releaseUnused gives back to the Allocator the arrays that aren't in use, without an Allocator they are
+// left to the GC. The slots left by Clear in them don't need to be zeroed anymore.
+func (v *Vec[T]) releaseUnused() {
+	kept := v.buffers[:0]
+	for _, buffer := range v.buffers {
+		if v.inUse(buffer) {
+			kept = append(kept, buffer)
+			continue
+		}
+		v.forgetDirty(buffer)
+		if !v.snapshots && v.alloc != nil {
+			v.alloc.Free(buffer)
+		}
+	}
+	for i := len(kept); i < len(v.buffers); i++ {
+		v.buffers[i] = nil
+	}
+	v.buffers = kept
+}
+
+// forgetDirty drops the slots left by Clear in the array of elements
+func (v *Vec[T]) forgetDirty(elements []T) {
+	kept := v.dirty[:0]
+	for _, dirty := range v.dirty {
+		if !sameArray(dirty, elements) {
+			kept = append(kept, dirty)
+		}
+	}
+	for i := len(kept); i < len(v.dirty); i++ {
+		v.dirty[i] = nil
+	}
+	v.dirty = kept
+}
+
+// Free empties the Vec and gives its storage back to its Allocator, the Vec can still be used afterwards.
+// Pointers returned by GetRef must not be used after it.
+func (v *Vec[T]) Free() {
+	for i := range v.dirty {
+		v.dirty[i] = nil
+	}
+	v.dirty = v.dirty[:0]
+	v.oldHead = nil
+	v.newTail = nil
+	v.oldShared = 0
+	v.newShared = 0
+	v.releaseUnused()
+	v.publish()
+}
+
+// needsZeroing returns true if the GC has to see zeros in the slots of the removed elements to collect
+// what they pointed to
+func needsZeroing[T any]() bool {
+	return hasPointers(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// vacate zeroes elements, which aren't part of the Vec anymore but are in an array it keeps using, if T has
+// pointers and the Vec owns the array. Nothing is zeroed on a Vec with snapshots, a view might still read it
+// (and the arrays aren't given back anyway).
+func (v *Vec[T]) vacate(elements []T) {
+	if len(elements) == 0 || v.snapshots || !needsZeroing[T]() || !v.owns(elements) {
+		return
+	}
+	zeroSlice(elements)
+}
+
+// zeroOld zeroes the slot of a removed element of the oldHead, unless a view can read it
+func (v *Vec[T]) zeroOld(index int) {
+	if !v.oldIsShared(index) {
+		v.vacate(v.oldHead[index : index+1])
+	}
+}
+
+// zeroNew zeroes the slot of a removed element of the newTail, unless a view can read it
+func (v *Vec[T]) zeroNew(index int) {
+	if !v.newIsShared(index) {
+		v.vacate(v.newTail[index : index+1])
+	}
+}
+
+// scrub zeroes up to n of the slots left by Clear
+func (v *Vec[T]) scrub(n int) {
+	for n > 0 && len(v.dirty) > 0 {
+		last := len(v.dirty) - 1
+		dirty := v.dirty[last]
+		k := min(n, len(dirty))
+		zeroSlice(dirty[len(dirty)-k:])
+		n -= k
+		if k < len(dirty) {
+			v.dirty[last] = dirty[:len(dirty)-k]
+			continue
+		}
+		v.dirty[last] = nil
+		v.dirty = v.dirty[:last]
+	}
+}
+
+// reclaimFront moves the newTail back to the start of its array once the capacity left behind by PopFront
+// and Clear is more than half of it (or right away if the Vec is empty), so it's used again. The elements
+// moved are less than the ones removed since the last time, so it's amortized by the removals.
+func (v *Vec[T]) reclaimFront() {
+	if v.snapshots || len(v.dirty) > 0 || v.oldLen() > 0 {
+		return
+	}
+	for _, buffer := range v.buffers {
+		if !sameArray(buffer, v.newTail) {
+			continue
+		}
+		dead := cap(buffer) - cap(v.newTail)
+		if dead == 0 || len(v.newTail) > 0 && dead <= cap(buffer)/2 {
+			return
+		}
+		n := copy(buffer[:len(v.newTail)], v.newTail)
+		// the slots the elements were moved from
+		v.vacate(buffer[max(n, dead) : dead+n])
+		v.newTail = buffer[:n]
+		return
+	}
+}
+
+// tidy does the zeroing left by Clear that corresponds to a change
+func (v *Vec[T]) tidy() {
+	if len(v.dirty) > 0 {
+		v.scrub(nItemsToZeroOnEachChange)
+	}
+	v.reclaimFront()
+}
+
+// sameArray returns true if both slices point into the same backing array
+func sameArray[T any](a, b []T) bool {
+	if cap(a) == 0 || cap(b) == 0 {
+		return false
+	}
+	// slices of the same array end where the array ends
+	return &a[:cap(a)][cap(a)-1] == &b[:cap(b)][cap(b)-1]
+}
+
+// sizeClass returns the power of two class that fits capacity elements
+func sizeClass(capacity int) int {
+	if capacity <= 1 {
+		return 0
+	}
+	return bits.Len(uint(capacity - 1))
+}
+
+// clearSlice zeroes every element up to the capacity, so a pooled array doesn't keep anything reachable nor
+// shows the elements of a Vec to the next one
+func clearSlice[T any](elements []T) {
+	zeroSlice(elements[:cap(elements)])
+}
+
+func zeroSlice[T any](elements []T) {
+	var zero T
+	for i := range elements {
+		elements[i] = zero
+	}
+}
+
+// PoolAllocator keeps the arrays it takes back in a sync.Pool per power of two size class, so they are reused
+// by the next Alloc of that class and dropped by the GC when nobody asks for them. It can be shared by
+// many Vecs and goroutines.
+type PoolAllocator[T any] struct {
+	pools [bits.UintSize]sync.Pool
+}
+
+// NewPoolAllocator returns a new PoolAllocator
+func NewPoolAllocator[T any]() *PoolAllocator[T] {
+	return &PoolAllocator[T]{}
+}
+
+// Alloc returns a slice whose capacity is capacity rounded up to a power of two
+func (a *PoolAllocator[T]) Alloc(capacity int) []T {
+	class := sizeClass(capacity)
+	if elements, ok := a.pools[class].Get().(*[]T); ok {
+		return (*elements)[:0]
+	}
+	return make([]T, 0, 1<<class)
+}
+
+// Free zeroes the slice and puts it in the pool of its size class, slices whose capacity isn't a power of two are
+// left to the GC
+func (a *PoolAllocator[T]) Free(elements []T) {
+	class := sizeClass(cap(elements))
+	if cap(elements) != 1<<class {
+		return
+	}
+	clearSlice(elements)
+	elements = elements[:0]
+	a.pools[class].Put(&elements)
+}
+
+// SizeClassAllocator keeps up to maxPerClass free arrays of each power of two size class. Unlike
+// PoolAllocator the GC never empties it. It can be shared by many Vecs and goroutines.
+type SizeClassAllocator[T any] struct {
+	mu          sync.Mutex
+	maxPerClass int
+	classes     [bits.UintSize][][]T
+}
+
+// NewSizeClassAllocator returns a new SizeClassAllocator that keeps up to maxPerClass free arrays per size class
+func NewSizeClassAllocator[T any](maxPerClass int) *SizeClassAllocator[T] {
+	return &SizeClassAllocator[T]{maxPerClass: maxPerClass}
+}
+
+// Alloc returns a slice whose capacity is capacity rounded up to a power of two
+func (a *SizeClassAllocator[T]) Alloc(capacity int) []T {
+	class := sizeClass(capacity)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	free := a.classes[class]
+	if len(free) == 0 {
+		return make([]T, 0, 1<<class)
+	}
+	elements := free[len(free)-1]
+	free[len(free)-1] = nil
+	a.classes[class] = free[:len(free)-1]
+	return elements
+}
+
+// Free zeroes the slice and keeps it for the next Alloc of its size class if there is room for it, slices whose
+// capacity isn't a power of two are left to the GC
+func (a *SizeClassAllocator[T]) Free(elements []T) {
+	class := sizeClass(cap(elements))
+	if cap(elements) != 1<<class {
+		return
+	}
+	clearSlice(elements)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.classes[class]) < a.maxPerClass {
+		a.classes[class] = append(a.classes[class], elements[:0])
+	}
+}
+
+// MmapAllocator allocates the arrays outside of the Go heap like ManualVec (mmap'ed memory on unix) and unmaps
+// them on Free. T must not contain pointers. Vecs using it must be freed with Vec.Free, and must not take
+// snapshots, their storage would never be unmapped.
+type MmapAllocator[T any] struct {
+	mu sync.Mutex
+	// mapped has the memory of every array handed out by its first element, Free ignores any other array
+	mapped map[uintptr][]byte
+}
+
+// NewMmapAllocator returns a new MmapAllocator, it panics if T contains pointers
+func NewMmapAllocator[T any]() *MmapAllocator[T] {
+	var zero T
+	if hasPointers(reflect.TypeOf(&zero).Elem()) {
+		panic("atone: MmapAllocator elements can't contain pointers")
+	}
+	return &MmapAllocator[T]{mapped: map[uintptr][]byte{}}
+}
+
+// Alloc maps room for capacity elements
+func (a *MmapAllocator[T]) Alloc(capacity int) []T {
+	elements, mem := manualAlloc[T](capacity)
+	if mem != nil {
+		a.mu.Lock()
+		a.mapped[uintptr(unsafe.Pointer(&elements[0]))] = mem
+		a.mu.Unlock()
+	}
+	return elements[:0]
+}
+
+// Free unmaps the slice if it was returned by Alloc
+func (a *MmapAllocator[T]) Free(elements []T) {
+	if cap(elements) == 0 {
+		return
+	}
+	key := uintptr(unsafe.Pointer(&elements[:1][0]))
+	a.mu.Lock()
+	mem, ok := a.mapped[key]
+	delete(a.mapped, key)
+	a.mu.Unlock()
+	if ok {
+		freeManualMemory(mem)
+	}
+}
+
+// CountingAllocator counts the arrays and elements that go through another Allocator, it's meant for tests
+// and for finding out how much memory Vecs use
+type CountingAllocator[T any] struct {
+	alloc  Allocator[T]
+	allocs int64
+	frees  int64
+	inUse  int64
+}
+
+// NewCountingAllocator returns a CountingAllocator over alloc, if alloc is nil the arrays are allocated with make
+func NewCountingAllocator[T any](alloc Allocator[T]) *CountingAllocator[T] {
+	return &CountingAllocator[T]{alloc: alloc}
+}
+
+// Alloc allocates the slice with the underlying Allocator and counts it
+func (a *CountingAllocator[T]) Alloc(capacity int) []T {
+	var elements []T
+	if a.alloc == nil {
+		elements = make([]T, 0, capacity)
+	} else {
+		elements = a.alloc.Alloc(capacity)
+	}
+	atomic.AddInt64(&a.allocs, 1)
+	atomic.AddInt64(&a.inUse, int64(cap(elements)))
+	return elements
+}
+
+// Free counts the slice and gives it to the underlying Allocator
+func (a *CountingAllocator[T]) Free(elements []T) {
+	atomic.AddInt64(&a.frees, 1)
+	atomic.AddInt64(&a.inUse, -int64(cap(elements)))
+	if a.alloc != nil {
+		a.alloc.Free(elements)
+	}
+}
+
+// Allocs returns the number of calls to Alloc
+func (a *CountingAllocator[T]) Allocs() int {
+	return int(atomic.LoadInt64(&a.allocs))
+}
+
+// Frees returns the number of calls to Free
+func (a *CountingAllocator[T]) Frees() int {
+	return int(atomic.LoadInt64(&a.frees))
+}
+
+// InUse returns the capacity, in elements, of the arrays that have been allocated and not freed yet
+func (a *CountingAllocator[T]) InUse() int {
+	return int(atomic.LoadInt64(&a.inUse))
+}
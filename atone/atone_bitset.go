@@ -0,0 +1,121 @@
+package atone
+
+import "math/bits"
+
+// Bitset is a set of bits backed by a Vec[uint64], setting a bit past the end grows it incrementally
+type Bitset struct {
+	words *Vec[uint64]
+}
+
+// NewBitset returns a new empty Bitset
+func NewBitset() *Bitset {
+	return &Bitset{words: New[uint64]()}
+}
+
+// NewBitsetWithCapacity returns a new empty Bitset that fits nBits bits without growing
+func NewBitsetWithCapacity(nBits uint64) *Bitset {
+	return &Bitset{words: NewWithCapacity[uint64]((nBits + 63) / 64)}
+}
+
+func (b *Bitset) word(i int) uint64 {
+	w, _ := b.words.Lookup(i)
+	return w
+}
+
+// growTo makes sure there are at least n words
+func (b *Bitset) growTo(n int) {
+	if missing := n - b.words.Len(); missing > 0 {
+		b.words.extend(make([]uint64, missing))
+	}
+}
+
+// Set sets the bit i
+func (b *Bitset) Set(i uint64) {
+	b.growTo(int(i/64) + 1)
+	*b.words.GetRef(int(i / 64)) |= 1 << (i % 64)
+}
+
+// Clear clears the bit i
+func (b *Bitset) Clear(i uint64) {
+	if int(i/64) < b.words.Len() {
+		*b.words.GetRef(int(i / 64)) &^= 1 << (i % 64)
+	}
+}
+
+// Test returns true if the bit i is set
+func (b *Bitset) Test(i uint64) bool {
+	return b.word(int(i/64))&(1<<(i%64)) != 0
+}
+
+// Len returns the number of bits the Bitset can hold without growing
+func (b *Bitset) Len() uint64 {
+	return uint64(b.words.Len()) * 64
+}
+
+// Count returns the number of bits set
+func (b *Bitset) Count() int {
+	count := 0
+	b.words.All()(func(_ int, w uint64) bool {
+		count += bits.OnesCount64(w)
+		return true
+	})
+	return count
+}
+
+// NextSet returns the first bit set starting from i (included), the boolean is false if there isn't any
+func (b *Bitset) NextSet(i uint64) (uint64, bool) {
+	index := int(i / 64)
+	if index >= b.words.Len() {
+		return 0, false
+	}
+	w := b.words.Get(index) >> (i % 64)
+	if w != 0 {
+		return i + uint64(bits.TrailingZeros64(w)), true
+	}
+	for index++; index < b.words.Len(); index++ {
+		if w := b.words.Get(index); w != 0 {
+			return uint64(index)*64 + uint64(bits.TrailingZeros64(w)), true
+		}
+	}
+	return 0, false
+}
+
+// Reset clears every bit
+func (b *Bitset) Reset() {
+	b.words.Clear()
+}
+
+// And keeps only the bits that are also set in other
+func (b *Bitset) And(other *Bitset) {
+	for i, n := 0, b.words.Len(); i < n; i++ {
+		*b.words.GetRef(i) &= other.word(i)
+	}
+}
+
+// Or sets the bits that are set in other
+func (b *Bitset) Or(other *Bitset) {
+	b.growTo(other.words.Len())
+	for i, n := 0, other.words.Len(); i < n; i++ {
+		*b.words.GetRef(i) |= other.words.Get(i)
+	}
+}
+
+// Xor flips the bits that are set in other
+func (b *Bitset) Xor(other *Bitset) {
+	b.growTo(other.words.Len())
+	for i, n := 0, other.words.Len(); i < n; i++ {
+		*b.words.GetRef(i) ^= other.words.Get(i)
+	}
+}
+
+// AndNot clears the bits that are set in other
+func (b *Bitset) AndNot(other *Bitset) {
+	for i, n := 0, min(b.words.Len(), other.words.Len()); i < n; i++ {
+		*b.words.GetRef(i) &^= other.words.Get(i)
+	}
+}
+
+// Clone returns a copy of the Bitset
+func (b *Bitset) Clone() *Bitset {
+	return &Bitset{words: From(b.words.Array())}
+}
@@ -0,0 +1,191 @@
+package atone
+
+import (
+	"errors"
+	"io"
+	"net"
+)
+
+// ByteVec is a byte buffer like bytes.Buffer but backed by a Vec[byte], so writing to it never stalls to copy
+// the whole buffer when it grows. Reads consume the buffer from the front.
+type ByteVec struct {
+	vec Vec[byte]
+	// lastRead is the last byte read, canUnread is true if the last operation was a read of at least a byte
+	lastRead  byte
+	canUnread bool
+	// unread is true if UnreadByte put lastRead back in front of the buffer
+	unread bool
+}
+
+// byteVecMinRead is the minimum free space ReadFrom asks for before each Read
+const byteVecMinRead = 512
+
+// NewByteVec returns a new empty ByteVec
+func NewByteVec() *ByteVec {
+	return &ByteVec{}
+}
+
+// ByteVecFrom returns a new ByteVec that reads from b, it takes ownership of b
+func ByteVecFrom(b []byte) *ByteVec {
+	return &ByteVec{vec: Vec[byte]{newTail: b}}
+}
+
+// Len returns the number of unread bytes
+func (b *ByteVec) Len() int {
+	if b.unread {
+		return b.vec.Len() + 1
+	}
+	return b.vec.Len()
+}
+
+// Reset empties the buffer
+func (b *ByteVec) Reset() {
+	b.vec.Clear()
+	b.unread = false
+	b.canUnread = false
+}
+
+// Bytes returns a copy of the unread bytes
+func (b *ByteVec) Bytes() []byte {
+	elements := make([]byte, 0, b.Len())
+	if b.unread {
+		elements = append(elements, b.lastRead)
+	}
+	elements = append(elements, b.vec.oldHead...)
+	return append(elements, b.vec.newTail...)
+}
+
+// String returns the unread bytes as a string
+func (b *ByteVec) String() string {
+	return string(b.Bytes())
+}
+
+// Write appends p to the buffer, it never fails
+func (b *ByteVec) Write(p []byte) (int, error) {
+	b.canUnread = false
+	b.vec.extend(p)
+	return len(p), nil
+}
+
+// WriteString appends s to the buffer, it never fails
+func (b *ByteVec) WriteString(s string) (int, error) {
+	return b.Write([]byte(s))
+}
+
+// WriteByte appends c to the buffer, it never fails
+func (b *ByteVec) WriteByte(c byte) error {
+	b.canUnread = false
+	b.vec.Push(c)
+	return nil
+}
+
+// Read reads the next len(p) bytes from the buffer, returns io.EOF if the buffer is empty
+func (b *ByteVec) Read(p []byte) (int, error) {
+	if b.Len() == 0 {
+		b.canUnread = false
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	n := 0
+	if b.unread && len(p) > 0 {
+		p[0] = b.lastRead
+		b.unread = false
+		n++
+	}
+	m := copy(p[n:], b.vec.oldHead)
+	m += copy(p[n+m:], b.vec.newTail)
+	b.vec.dropFront(m)
+	n += m
+	if n > 0 {
+		b.lastRead = p[n-1]
+		b.canUnread = true
+	}
+	return n, nil
+}
+
+// ReadByte reads the next byte from the buffer, returns io.EOF if the buffer is empty
+func (b *ByteVec) ReadByte() (byte, error) {
+	if b.unread {
+		b.unread = false
+		b.canUnread = true
+		return b.lastRead, nil
+	}
+	if b.vec.IsEmpty() {
+		b.canUnread = false
+		return 0, io.EOF
+	}
+	c := b.vec.PopFront()
+	b.lastRead = c
+	b.canUnread = true
+	return c, nil
+}
+
+// UnreadByte unreads the last byte returned by the last read operation
+func (b *ByteVec) UnreadByte() error {
+	if !b.canUnread {
+		return errUnreadByte
+	}
+	b.unread = true
+	b.canUnread = false
+	return nil
+}
+
+// WriteTo writes the whole buffer to w. The old and the new segments are written with a single vectored
+// write (writev) if w supports it, instead of flattening them.
+func (b *ByteVec) WriteTo(w io.Writer) (int64, error) {
+	b.canUnread = false
+	total := int64(0)
+	if b.unread {
+		n, err := w.Write([]byte{b.lastRead})
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+		b.unread = false
+	}
+	buffers := make(net.Buffers, 0, 2)
+	if len(b.vec.oldHead) > 0 {
+		buffers = append(buffers, b.vec.oldHead)
+	}
+	if len(b.vec.newTail) > 0 {
+		buffers = append(buffers, b.vec.newTail)
+	}
+	n, err := buffers.WriteTo(w)
+	b.vec.dropFront(int(n))
+	return total + n, err
+}
+
+// ReadFrom reads from r until io.EOF and appends everything to the buffer, reading directly into the
+// free space of the newTail
+func (b *ByteVec) ReadFrom(r io.Reader) (int64, error) {
+	b.canUnread = false
+	v := &b.vec
+	total := int64(0)
+	for {
+		if cap(v.newTail)-len(v.newTail) < byteVecMinRead {
+			v.grow(byteVecMinRead)
+		}
+		v.ownNew(len(v.newTail))
+		free := v.newTail[len(v.newTail):cap(v.newTail)]
+		n, err := r.Read(free)
+		if n < 0 {
+			panic("atone: reader returned negative count from Read")
+		}
+		v.newTail = v.newTail[:len(v.newTail)+n]
+		if v.oldLen() != 0 {
+			v.carryN(n * NItemsToMoveOnEachInsert)
+		}
+		v.publish()
+		total += int64(n)
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+var errUnreadByte = errors.New("atone: UnreadByte: previous operation was not a successful read")
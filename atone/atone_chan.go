@@ -0,0 +1,70 @@
+package atone
+
+import "context"
+
+// FromChan returns a new Vec with every element received from ch until it's closed or ctx is done, in
+// which case the Vec contains the elements received until then
+func FromChan[T any](ctx context.Context, ch <-chan T) *Vec[T] {
+	v := New[T]()
+	for {
+		select {
+		case <-ctx.Done():
+			return v
+		case el, ok := <-ch:
+			if !ok {
+				return v
+			}
+			v.Push(el)
+		}
+	}
+}
+
+// SendTo sends every element of the Vec in order to ch, it returns ctx.Err() if ctx is done before
+// all of them are sent. The Vec must not be changed until it returns.
+func (v *Vec[T]) SendTo(ctx context.Context, ch chan<- T) error {
+	if err := sendTo(ctx, v.oldHead, ch); err != nil {
+		return err
+	}
+	return sendTo(ctx, v.newTail, ch)
+}
+
+func sendTo[T any](ctx context.Context, els []T, ch chan<- T) error {
+	for i := range els {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ch <- els[i]:
+		}
+	}
+	return nil
+}
+
+// PushChan returns a channel whose values are pushed into the Vec by a goroutine that owns the Vec, every value
+// that was sent before ctx is done is pushed. After that the values are received and discarded, so senders never
+// block. The caller must close the channel: the goroutine returns after that, sending the number of values it
+// discarded on the second channel and closing it, the Vec must not be used before.
+func (v *Vec[T]) PushChan(ctx context.Context) (chan<- T, <-chan int) {
+	ch := make(chan T)
+	done := make(chan int, 1)
+	go func() {
+		discarded := 0
+		defer func() {
+			done <- discarded
+			close(done)
+		}()
+		for ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+			case el, ok := <-ch:
+				if !ok {
+					return
+				}
+				v.Push(el)
+			}
+		}
+		for range ch {
+			discarded++
+		}
+	}()
+	return ch, done
+}
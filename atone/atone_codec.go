@@ -0,0 +1,63 @@
+package atone
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+)
+
+// Codec encodes elements to bytes and back, it's how the Vecs that keep elements on disk (DurableVec,
+// SpillVec...) store them
+type Codec[T any] interface {
+	// Encode appends the encoding of el to dst and returns the extended slice
+	Encode(dst []byte, el T) ([]byte, error)
+	// Decode decodes an element from src, which is exactly one encoding returned by Encode
+	Decode(src []byte) (T, error)
+}
+
+// GobCodec encodes each element on its own with encoding/gob, it works for any type gob supports
+type GobCodec[T any] struct{}
+
+// Encode appends the gob encoding of el to dst
+func (GobCodec[T]) Encode(dst []byte, el T) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	if err := gob.NewEncoder(buf).Encode(&el); err != nil {
+		return dst, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode decodes an element encoded by Encode
+func (GobCodec[T]) Decode(src []byte) (T, error) {
+	var el T
+	err := gob.NewDecoder(bytes.NewReader(src)).Decode(&el)
+	return el, err
+}
+
+// FixedCodec encodes fixed size elements (numbers, and arrays and structs of them) with encoding/binary in
+// little endian, it's much faster and more compact than GobCodec
+type FixedCodec[T any] struct{}
+
+var errFixedCodecSize = errors.New("atone: FixedCodec can only encode fixed size types")
+
+// Encode appends the binary encoding of el to dst
+func (FixedCodec[T]) Encode(dst []byte, el T) ([]byte, error) {
+	size := binary.Size(el)
+	if size < 0 {
+		return dst, errFixedCodecSize
+	}
+	buf := bytes.NewBuffer(dst)
+	buf.Grow(size)
+	if err := binary.Write(buf, binary.LittleEndian, el); err != nil {
+		return dst, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode decodes an element encoded by Encode
+func (FixedCodec[T]) Decode(src []byte) (T, error) {
+	var el T
+	err := binary.Read(bytes.NewReader(src), binary.LittleEndian, &el)
+	return el, err
+}
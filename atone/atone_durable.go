@@ -0,0 +1,580 @@
+package atone
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DurableVec is a Vec whose changes (Push, PopBack and Truncate) are written to an append only log before
+// they are applied, so it can be rebuilt after a crash. Once the log has CompactEvery records, and at least as
+// many as the elements of the last snapshot so writing it costs O(1) per record, the whole Vec is written to a
+// snapshot file and the log starts over. On open the snapshot is loaded and the log is replayed on top of it,
+// a torn record at the end of the log (a crash in the middle of a write) is dropped.
+//
+// Like Vec, it must only be used by one goroutine at a time.
+type DurableVec[T any] struct {
+	vec   *Vec[T]
+	codec Codec[T]
+	dir   string
+	opts  DurableOptions
+
+	// mu guards the log, the interval syncer uses it too
+	mu  sync.Mutex
+	log *os.File
+	// seq is the sequence number of the last record written
+	seq uint64
+	// size is the length of the log up to the last complete record
+	size int64
+	// records is the number of records in the log
+	records int
+	// snapshotLen is the number of elements in the last snapshot
+	snapshotLen int
+	// unsynced is the number of records written since the last fsync
+	unsynced int
+	buf      []byte
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// SyncMode is when DurableVec fsyncs the log
+type SyncMode int
+
+const (
+	// SyncEveryOp fsyncs every record before the change returns, nothing acknowledged is ever lost
+	SyncEveryOp SyncMode = iota
+	// SyncBatch fsyncs once BatchSize records have been written, a crash loses at most the last batch
+	SyncBatch
+	// SyncInterval fsyncs every Interval from a background goroutine, a crash loses at most the last Interval
+	SyncInterval
+)
+
+// DurableOptions configures a DurableVec
+type DurableOptions struct {
+	Sync SyncMode
+	// BatchSize is the number of records per fsync with SyncBatch
+	BatchSize int
+	// Interval is the time between fsyncs with SyncInterval
+	Interval time.Duration
+	// CompactEvery is the minimum number of log records after which the log is compacted into a snapshot, 0
+	// only compacts on Compact
+	CompactEvery int
+}
+
+const (
+	defaultDurableBatchSize    = 64
+	defaultDurableInterval     = 100 * time.Millisecond
+	defaultDurableCompactEvery = 1 << 16
+)
+
+const (
+	durableLogName      = "wal"
+	durableSnapshotName = "snapshot"
+	durableVersion      = 2
+	// durableRecordHeader is the size of the length, the checksum of the body and the checksum of both before
+	// every record, so a broken length isn't taken for a torn record
+	durableRecordHeader = 12
+)
+
+const (
+	opPush byte = iota + 1
+	opPopBack
+	opTruncate
+)
+
+var durableSnapshotMagic = [8]byte{'A', 'T', 'O', 'N', 'E', 'S', 'N', 'P'}
+
+var (
+	// ErrDurableCorrupt is returned by OpenDurableVec when the snapshot or a record in the middle of the log is
+	// corrupted, or a record can't be applied
+	ErrDurableCorrupt = errors.New("atone: the DurableVec files are corrupted")
+	// ErrDurableTruncate is returned by DurableVec.Truncate when the length is negative
+	ErrDurableTruncate = errors.New("atone: DurableVec can't be truncated to a negative length")
+)
+
+// OpenDurableVec opens the DurableVec stored in dir, creating it if it doesn't exist. Every change is fsynced
+// before it returns.
+func OpenDurableVec[T any](dir string, codec Codec[T]) (*DurableVec[T], error) {
+	return OpenDurableVecWithOptions(dir, codec, DurableOptions{Sync: SyncEveryOp, CompactEvery: defaultDurableCompactEvery})
+}
+
+// OpenDurableVecWithOptions opens the DurableVec stored in dir, creating it if it doesn't exist
+func OpenDurableVecWithOptions[T any](dir string, codec Codec[T], opts DurableOptions) (*DurableVec[T], error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultDurableBatchSize
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultDurableInterval
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	d := &DurableVec[T]{vec: New[T](), codec: codec, dir: dir, opts: opts}
+	if err := d.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	log, err := os.OpenFile(filepath.Join(dir, durableLogName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	d.log = log
+	if err := d.replay(); err != nil {
+		log.Close()
+		return nil, err
+	}
+	if opts.Sync == SyncInterval {
+		d.stop = make(chan struct{})
+		d.wg.Add(1)
+		go d.syncEvery(opts.Interval)
+	}
+	return d, nil
+}
+
+// readRecord reads the record at the start of r, which has remaining bytes. It returns io.ErrUnexpectedEOF if
+// the header goes past the end, the body of a valid header does, or the body ends right at the end and doesn't
+// match its checksum (all of them are a torn write of the last record), and ErrDurableCorrupt if the header or
+// the body of a record followed by more data don't match their checksums.
+func readRecord(r io.Reader, remaining int64) ([]byte, error) {
+	var header [durableRecordHeader]byte
+	if remaining < durableRecordHeader {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	if crc32.Checksum(header[0:8], fileVecTable) != binary.LittleEndian.Uint32(header[8:12]) {
+		return nil, ErrDurableCorrupt
+	}
+	length := binary.LittleEndian.Uint32(header[0:4])
+	if int64(length) > remaining-durableRecordHeader {
+		return nil, io.ErrUnexpectedEOF
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	if crc32.Checksum(body, fileVecTable) != binary.LittleEndian.Uint32(header[4:8]) {
+		if int64(length) == remaining-durableRecordHeader {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, ErrDurableCorrupt
+	}
+	return body, nil
+}
+
+// setRecordHeader sets the length and checksum of the record in b, whose body starts after the header
+func setRecordHeader(b []byte) {
+	body := b[durableRecordHeader:]
+	binary.LittleEndian.PutUint32(b[0:4], uint32(len(body)))
+	binary.LittleEndian.PutUint32(b[4:8], crc32.Checksum(body, fileVecTable))
+	binary.LittleEndian.PutUint32(b[8:12], crc32.Checksum(b[0:8], fileVecTable))
+}
+
+// onlyZeros returns true if the file only has zeros from offset to size, which is what is left after a crash
+// on filesystems that grow the file before writing the data
+func onlyZeros(file *os.File, offset, size int64) (bool, error) {
+	buf := make([]byte, 32*1024)
+	for offset < size {
+		n, err := file.ReadAt(buf[:min64(size-offset, int64(len(buf)))], offset)
+		for _, c := range buf[:n] {
+			if c != 0 {
+				return false, nil
+			}
+		}
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		if n == 0 {
+			break
+		}
+		offset += int64(n)
+	}
+	return true, nil
+}
+
+func min64(n, n2 int64) int64 {
+	if n < n2 {
+		return n
+	}
+	return n2
+}
+
+// loadSnapshot reads the snapshot file if there is one, it has a header with the sequence number of the last
+// record it includes and the number of elements, followed by a record per element
+func (d *DurableVec[T]) loadSnapshot() error {
+	file, err := os.Open(filepath.Join(d.dir, durableSnapshotName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	remaining := info.Size()
+	r := bufio.NewReader(file)
+	body, err := readRecord(r, remaining)
+	if err != nil || len(body) != 28 || *(*[8]byte)(body[0:8]) != durableSnapshotMagic ||
+		binary.LittleEndian.Uint32(body[8:12]) != durableVersion {
+		return ErrDurableCorrupt
+	}
+	remaining -= int64(durableRecordHeader + len(body))
+	d.seq = binary.LittleEndian.Uint64(body[12:20])
+	count := binary.LittleEndian.Uint64(body[20:28])
+	for i := uint64(0); i < count; i++ {
+		body, err := readRecord(r, remaining)
+		if err != nil {
+			return ErrDurableCorrupt
+		}
+		remaining -= int64(durableRecordHeader + len(body))
+		el, err := d.codec.Decode(body)
+		if err != nil {
+			return err
+		}
+		d.vec.Push(el)
+	}
+	d.snapshotLen = d.vec.Len()
+	return nil
+}
+
+// replay applies the records of the log that are newer than the snapshot, and cuts the log after the last
+// good record. The log is only cut when the rest of it is a torn record, which is a header cut off by the end
+// of the file, the body of a valid header cut off by it or not matching its checksum, or zeros; anything else
+// is left as it is and ErrDurableCorrupt is returned.
+func (d *DurableVec[T]) replay() error {
+	info, err := d.log.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	if _, err := d.log.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	r := bufio.NewReader(d.log)
+	good := int64(0)
+	for good < size {
+		body, err := readRecord(r, size-good)
+		if err == nil && len(body) < 9 {
+			err = ErrDurableCorrupt
+		}
+		if err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err == ErrDurableCorrupt {
+			// the zeros of a write that never happened
+			zeros, err := onlyZeros(d.log, good, size)
+			if err != nil {
+				return err
+			}
+			if !zeros {
+				return ErrDurableCorrupt
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+		good += int64(durableRecordHeader + len(body))
+		seq := binary.LittleEndian.Uint64(body[0:8])
+		d.records++
+		if seq <= d.seq {
+			// the snapshot already has it, the log wasn't truncated after the last compaction
+			continue
+		}
+		d.seq = seq
+		if err := d.apply(body[8], body[9:]); err != nil {
+			return err
+		}
+	}
+	if err := d.log.Truncate(good); err != nil {
+		return err
+	}
+	d.size = good
+	_, err = d.log.Seek(good, io.SeekStart)
+	return err
+}
+
+// apply applies a record of the log, it returns ErrDurableCorrupt if it's not a change DurableVec could have made
+func (d *DurableVec[T]) apply(op byte, payload []byte) error {
+	switch op {
+	case opPush:
+		el, err := d.codec.Decode(payload)
+		if err != nil {
+			return ErrDurableCorrupt
+		}
+		d.vec.Push(el)
+	case opPopBack:
+		if len(payload) != 0 || d.vec.IsEmpty() {
+			return ErrDurableCorrupt
+		}
+		d.vec.PopBack()
+	case opTruncate:
+		if len(payload) != 8 || binary.LittleEndian.Uint64(payload) >= uint64(d.vec.Len()) {
+			return ErrDurableCorrupt
+		}
+		d.vec.Truncate(int(binary.LittleEndian.Uint64(payload)))
+	default:
+		return ErrDurableCorrupt
+	}
+	return nil
+}
+
+// write appends a record to the log and fsyncs it as the SyncMode says
+func (d *DurableVec[T]) write(op byte, el *T, n int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	b := append(d.buf[:0], make([]byte, durableRecordHeader+8)...)
+	binary.LittleEndian.PutUint64(b[durableRecordHeader:], d.seq+1)
+	b = append(b, op)
+	switch op {
+	case opPush:
+		var err error
+		if b, err = d.codec.Encode(b, *el); err != nil {
+			return err
+		}
+	case opTruncate:
+		var payload [8]byte
+		binary.LittleEndian.PutUint64(payload[:], uint64(n))
+		b = append(b, payload[:]...)
+	}
+	setRecordHeader(b)
+	d.buf = b
+	if _, err := d.log.Write(b); err != nil {
+		d.cut()
+		return err
+	}
+	d.unsynced++
+	if d.opts.Sync == SyncEveryOp || d.opts.Sync == SyncBatch && d.unsynced >= d.opts.BatchSize {
+		if err := d.syncLocked(); err != nil {
+			// the change is not applied, so its record must not be replayed either
+			d.unsynced--
+			d.cut()
+			return err
+		}
+	}
+	d.size += int64(len(b))
+	d.seq++
+	d.records++
+	return nil
+}
+
+// cut removes what was written after the last complete record, so there is no half record in the middle of the log
+func (d *DurableVec[T]) cut() {
+	if d.log.Truncate(d.size) == nil {
+		d.log.Seek(d.size, io.SeekStart)
+	}
+}
+
+func (d *DurableVec[T]) syncLocked() error {
+	if d.unsynced == 0 {
+		return nil
+	}
+	if err := d.log.Sync(); err != nil {
+		return err
+	}
+	d.unsynced = 0
+	return nil
+}
+
+func (d *DurableVec[T]) syncEvery(interval time.Duration) {
+	defer d.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			d.mu.Lock()
+			// the error shows up again on the next Sync or Close
+			d.syncLocked()
+			d.mu.Unlock()
+		}
+	}
+}
+
+// compactIfNeeded compacts the log once it has CompactEvery records, and as many records as the elements of the
+// last snapshot so compacting a big Vec doesn't stall every CompactEvery records
+func (d *DurableVec[T]) compactIfNeeded() error {
+	if d.opts.CompactEvery > 0 && d.records >= max(d.opts.CompactEvery, d.snapshotLen) {
+		return d.Compact()
+	}
+	return nil
+}
+
+// Push pushes back an element, it's applied once it's in the log
+func (d *DurableVec[T]) Push(el T) error {
+	if err := d.write(opPush, &el, 0); err != nil {
+		return err
+	}
+	d.vec.Push(el)
+	return d.compactIfNeeded()
+}
+
+// PopBack pops the last element, returns null if the DurableVec is empty
+func (d *DurableVec[T]) PopBack() (T, error) {
+	var t T
+	if d.vec.IsEmpty() {
+		return t, nil
+	}
+	if err := d.write(opPopBack, nil, 0); err != nil {
+		return t, err
+	}
+	popped := d.vec.PopBack()
+	return popped, d.compactIfNeeded()
+}
+
+// Truncate only will mantain only the first 'n' elements, it returns ErrDurableTruncate if n is negative
+func (d *DurableVec[T]) Truncate(n int) error {
+	if n < 0 {
+		return ErrDurableTruncate
+	}
+	if n >= d.vec.Len() {
+		return nil
+	}
+	if err := d.write(opTruncate, nil, n); err != nil {
+		return err
+	}
+	d.vec.Truncate(n)
+	return d.compactIfNeeded()
+}
+
+// Compact writes every element to a new snapshot file and empties the log
+func (d *DurableVec[T]) Compact() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tmp := filepath.Join(d.dir, durableSnapshotName+".tmp")
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	err = d.writeSnapshot(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(d.dir, durableSnapshotName)); err != nil {
+		return err
+	}
+	if err := syncDir(d.dir); err != nil {
+		return err
+	}
+	// the records of the log are in the snapshot now, if we crash before this they are skipped by their seq
+	if err := d.log.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := d.log.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	d.size = 0
+	d.records = 0
+	d.unsynced = 0
+	d.snapshotLen = d.vec.Len()
+	return d.log.Sync()
+}
+
+func (d *DurableVec[T]) writeSnapshot(file *os.File) error {
+	w := bufio.NewWriter(file)
+	b := make([]byte, durableRecordHeader+28)
+	copy(b[durableRecordHeader:], durableSnapshotMagic[:])
+	binary.LittleEndian.PutUint32(b[durableRecordHeader+8:], durableVersion)
+	binary.LittleEndian.PutUint64(b[durableRecordHeader+12:], d.seq)
+	binary.LittleEndian.PutUint64(b[durableRecordHeader+20:], uint64(d.vec.Len()))
+	setRecordHeader(b)
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+	var err error
+	d.vec.All()(func(_ int, el T) bool {
+		b = append(b[:0], make([]byte, durableRecordHeader)...)
+		if b, err = d.codec.Encode(b, el); err != nil {
+			return false
+		}
+		setRecordHeader(b)
+		_, err = w.Write(b)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// syncDir fsyncs a directory so the files renamed into it are durable
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := f.Sync(); err != nil && !errors.Is(err, os.ErrInvalid) {
+		return err
+	}
+	return nil
+}
+
+// Sync fsyncs the records that haven't been fsynced yet
+func (d *DurableVec[T]) Sync() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.syncLocked()
+}
+
+// Close syncs and closes the log, the DurableVec must not be used after it
+func (d *DurableVec[T]) Close() error {
+	if d.stop != nil {
+		close(d.stop)
+		d.wg.Wait()
+	}
+	err := d.Sync()
+	if closeErr := d.log.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Len returns the number of elements stored in the array
+func (d *DurableVec[T]) Len() int {
+	return d.vec.Len()
+}
+
+// IsEmpty returns if there is any element in the array or not
+func (d *DurableVec[T]) IsEmpty() bool {
+	return d.vec.IsEmpty()
+}
+
+// Get returns the element in the specified index, can panic if it is outofbounds, if you don't want to panic on get, use Lookup
+func (d *DurableVec[T]) Get(index int) T {
+	return d.vec.Get(index)
+}
+
+// Lookup returns an element, the boolean is false if the element does not exist.
+func (d *DurableVec[T]) Lookup(index int) (T, bool) {
+	return d.vec.Lookup(index)
+}
+
+// All returns an iterator over the indexes and elements (from Go 1.23 it can be used with range over func)
+func (d *DurableVec[T]) All() func(yield func(index int, el T) bool) {
+	return d.vec.All()
+}
+
+// Iter generates an array of elements (allocates space for the iteration)
+func (d *DurableVec[T]) Iter() []T {
+	return d.vec.Iter()
+}
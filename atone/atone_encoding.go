@@ -0,0 +1,179 @@
+package atone
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"reflect"
+	"unsafe"
+)
+
+// A Vec is encoded as a header followed by its elements:
+//
+//	[u8 version][u8 encoding][u8 kind][u8 0][u64 len][elements]
+//
+// with every number in little endian. The elements of fixed size numeric types (vecEncodingFixed) are
+// stored one after another in little endian, with kind being their reflect.Kind so they are not decoded
+// as a different type. Any other type (vecEncodingGob) is stored as a gob stream of the elements.
+const (
+	vecEncodingVersion    = 1
+	vecEncodingHeaderSize = 12
+
+	vecEncodingFixed = 1
+	vecEncodingGob   = 2
+
+	// vecDecodeChunk is the number of fixed size elements that are decoded at once before appending them
+	vecDecodeChunk = 4096
+)
+
+// ErrVecEncoding is returned by UnmarshalBinary when the data is not an encoded Vec of the same type
+var ErrVecEncoding = errors.New("atone: invalid or unsupported Vec encoding")
+
+// littleEndian is true if the machine stores numbers in little endian, so fixed size elements can be copied
+// as they are
+var littleEndian = func() bool {
+	n := uint16(1)
+	return *(*byte)(unsafe.Pointer(&n)) == 1
+}()
+
+// fixedKind returns the kind of T if the Vec can be encoded with vecEncodingFixed, 0 if it can't. int, uint
+// and uintptr are left to gob because their size depends on the machine.
+func fixedKind[T any]() reflect.Kind {
+	var zero T
+	switch kind := reflect.TypeOf(&zero).Elem().Kind(); kind {
+	case reflect.Bool, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return kind
+	}
+	return reflect.Invalid
+}
+
+// sliceBytes returns the memory of the elements, T must not contain pointers
+func sliceBytes[T any](elements []T) []byte {
+	if len(elements) == 0 {
+		return nil
+	}
+	var zero T
+	return unsafe.Slice((*byte)(unsafe.Pointer(&elements[0])), len(elements)*int(unsafe.Sizeof(zero)))
+}
+
+// MarshalBinary encodes the elements of the Vec (it implements encoding.BinaryMarshaler). Fixed size numbers
+// are copied as they are, any other type is encoded with gob.
+func (v *Vec[T]) MarshalBinary() ([]byte, error) {
+	var zero T
+	kind := fixedKind[T]()
+	size := vecEncodingHeaderSize
+	if kind != reflect.Invalid {
+		size += v.Len() * int(unsafe.Sizeof(zero))
+	}
+	b := make([]byte, vecEncodingHeaderSize, size)
+	b[0] = vecEncodingVersion
+	b[1] = vecEncodingGob
+	if kind != reflect.Invalid {
+		b[1] = vecEncodingFixed
+		b[2] = byte(kind)
+	}
+	binary.LittleEndian.PutUint64(b[4:12], uint64(v.Len()))
+	buf := bytes.NewBuffer(b)
+	var enc *gob.Encoder
+	if kind == reflect.Invalid {
+		enc = gob.NewEncoder(buf)
+	}
+	// the segments are encoded where they are instead of copying them into a single slice first
+	for _, segment := range [2][]T{v.oldHead[:v.oldLen()], v.newTail} {
+		switch {
+		case kind == reflect.Invalid:
+			for i := range segment {
+				if err := enc.Encode(&segment[i]); err != nil {
+					return nil, err
+				}
+			}
+		case littleEndian:
+			buf.Write(sliceBytes(segment))
+		default:
+			if err := binary.Write(buf, binary.LittleEndian, segment); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary replaces the elements of the Vec with the ones encoded by MarshalBinary (it implements
+// encoding.BinaryUnmarshaler). The elements are appended like Append does, so it doesn't stall on big Vecs.
+// If the elements can't be decoded the Vec is left empty.
+func (v *Vec[T]) UnmarshalBinary(data []byte) error {
+	if len(data) < vecEncodingHeaderSize || data[0] != vecEncodingVersion || data[3] != 0 {
+		return ErrVecEncoding
+	}
+	encoding, kind := data[1], reflect.Kind(data[2])
+	n := binary.LittleEndian.Uint64(data[4:12])
+	data = data[vecEncodingHeaderSize:]
+	switch {
+	case encoding == vecEncodingFixed && kind == fixedKind[T]():
+		return v.unmarshalFixed(data, n)
+	case encoding == vecEncodingGob && kind == reflect.Invalid && fixedKind[T]() == reflect.Invalid:
+		return v.unmarshalGob(data, n)
+	}
+	return ErrVecEncoding
+}
+
+func (v *Vec[T]) unmarshalFixed(data []byte, n uint64) error {
+	var zero T
+	size := uint64(unsafe.Sizeof(zero))
+	if uint64(len(data))/size != n || uint64(len(data))%size != 0 {
+		return ErrVecEncoding
+	}
+	if fixedKind[T]() == reflect.Bool {
+		// a bool byte other than 0 or 1 is not a valid bool
+		for _, c := range data {
+			if c > 1 {
+				return ErrVecEncoding
+			}
+		}
+	}
+	v.Clear()
+	chunk := make([]T, min(int(n), vecDecodeChunk))
+	for len(data) > 0 {
+		elements := chunk[:min(len(chunk), len(data)/int(size))]
+		if littleEndian {
+			copy(sliceBytes(elements), data)
+		} else if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, elements); err != nil {
+			v.Clear()
+			return err
+		}
+		v.Append(elements...)
+		data = data[len(elements)*int(size):]
+	}
+	return nil
+}
+
+func (v *Vec[T]) unmarshalGob(data []byte, n uint64) error {
+	v.Clear()
+	r := bytes.NewReader(data)
+	dec := gob.NewDecoder(r)
+	for i := uint64(0); i < n; i++ {
+		var el T
+		if err := dec.Decode(&el); err != nil {
+			v.Clear()
+			return err
+		}
+		v.Push(el)
+	}
+	if r.Len() != 0 {
+		v.Clear()
+		return ErrVecEncoding
+	}
+	return nil
+}
+
+// GobEncode encodes the Vec with MarshalBinary (it implements gob.GobEncoder)
+func (v *Vec[T]) GobEncode() ([]byte, error) {
+	return v.MarshalBinary()
+}
+
+// GobDecode decodes a Vec encoded by GobEncode (it implements gob.GobDecoder)
+func (v *Vec[T]) GobDecode(data []byte) error {
+	return v.UnmarshalBinary(data)
+}
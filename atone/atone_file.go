@@ -0,0 +1,392 @@
+package atone
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"math/bits"
+	"os"
+	"reflect"
+	"unsafe"
+)
+
+// FileVec is a Vec of pointer free elements stored in a memory mapped file, so they survive a restart. The file
+// starts with a versioned header that has the size of the elements, the number of elements and a checksum of
+// the header, which are written by Sync and Close. Changes done after the last Sync might be lost on a crash,
+// and after a crash the FileVec has the length of the last Sync.
+//
+// The file grows by doubling like Vec does, but instead of mapping the whole file again only the new part is
+// mapped, so the elements already in the file are never moved or mapped again and references to them stay
+// valid. Where there is no mmap each part is read into memory and written back on Sync.
+type FileVec[T any] struct {
+	file *os.File
+	// segments are the mapped parts of the file, the first one has the header and first elements and every
+	// one after it doubles the capacity
+	segments []fileVecSegment[T]
+	// first is the capacity of the first segment
+	first    int
+	capacity int
+	len      int
+	// seq is the sequence number of the last header written
+	seq uint32
+}
+
+type fileVecSegment[T any] struct {
+	// offset is the position of mem in the file
+	offset   int64
+	mem      []byte
+	elements []T
+}
+
+// The header has two slots of fileVecSlotSize bytes that Sync writes in turns, so a crash while one of them is
+// being written still leaves the other one. Each slot is
+//
+//	[magic][u32 version][u32 element size][u64 len][u32 seq][u32 crc of the slot]
+//
+// with every number in little endian.
+const (
+	fileVecHeaderSize = 64
+	fileVecSlotSize   = 32
+	fileVecVersion    = 2
+	// minFileVecCapacity is the number of elements a new file has room for
+	minFileVecCapacity = 1024
+)
+
+var fileVecMagic = [8]byte{'A', 'T', 'O', 'N', 'E', 'V', 'E', 'C'}
+
+var fileVecTable = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	// ErrFileVecFormat is returned by OpenFileVec when the file is not a FileVec or its version is not supported
+	ErrFileVecFormat = errors.New("atone: not a FileVec file or unsupported version")
+	// ErrFileVecElementSize is returned by OpenFileVec when the file has elements of another size
+	ErrFileVecElementSize = errors.New("atone: the FileVec file has elements of a different size")
+	// ErrFileVecChecksum is returned by OpenFileVec when no header of the file matches its checksum
+	ErrFileVecChecksum = errors.New("atone: the FileVec file is corrupted")
+)
+
+// fileVecHeader is a slot of the header
+type fileVecHeader struct {
+	elementSize uint32
+	len         uint64
+	seq         uint32
+}
+
+func (h *fileVecHeader) encode(b []byte) {
+	copy(b[0:8], fileVecMagic[:])
+	binary.LittleEndian.PutUint32(b[8:12], fileVecVersion)
+	binary.LittleEndian.PutUint32(b[12:16], h.elementSize)
+	binary.LittleEndian.PutUint64(b[16:24], h.len)
+	binary.LittleEndian.PutUint32(b[24:28], h.seq)
+	binary.LittleEndian.PutUint32(b[28:32], crc32.Checksum(b[0:28], fileVecTable))
+}
+
+func (h *fileVecHeader) decode(b []byte) error {
+	if *(*[8]byte)(b[0:8]) != fileVecMagic || binary.LittleEndian.Uint32(b[8:12]) != fileVecVersion {
+		return ErrFileVecFormat
+	}
+	if binary.LittleEndian.Uint32(b[28:32]) != crc32.Checksum(b[0:28], fileVecTable) {
+		return ErrFileVecChecksum
+	}
+	h.elementSize = binary.LittleEndian.Uint32(b[12:16])
+	h.len = binary.LittleEndian.Uint64(b[16:24])
+	h.seq = binary.LittleEndian.Uint32(b[24:28])
+	return nil
+}
+
+// readFileVecHeader returns the newest slot of the header that is valid
+func readFileVecHeader(b []byte) (fileVecHeader, error) {
+	var newest fileVecHeader
+	err := ErrFileVecFormat
+	for slot := 0; slot < 2; slot++ {
+		var header fileVecHeader
+		switch slotErr := header.decode(b[slot*fileVecSlotSize : (slot+1)*fileVecSlotSize]); {
+		case slotErr == nil:
+			// the sequence number can wrap around
+			if err != nil || int32(header.seq-newest.seq) > 0 {
+				newest = header
+			}
+			err = nil
+		case err == ErrFileVecFormat:
+			err = slotErr
+		}
+	}
+	return newest, err
+}
+
+// OpenFileVec opens the FileVec stored in path, creating it if it doesn't exist. It panics if T contains
+// pointers or has size 0.
+func OpenFileVec[T any](path string) (*FileVec[T], error) {
+	var zero T
+	if hasPointers(reflect.TypeOf(&zero).Elem()) {
+		panic("atone: FileVec elements can't contain pointers")
+	}
+	size := int(unsafe.Sizeof(zero))
+	if size == 0 {
+		panic("atone: FileVec elements can't have size 0")
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	v, err := openFileVec[T](file, size)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return v, nil
+}
+
+func openFileVec[T any](file *os.File, size int) (*FileVec[T], error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	v := &FileVec[T]{file: file}
+	var header fileVecHeader
+	capacity := 0
+	if info.Size() > 0 {
+		if info.Size() < fileVecHeaderSize {
+			return nil, ErrFileVecFormat
+		}
+		var buf [fileVecHeaderSize]byte
+		if _, err := file.ReadAt(buf[:], 0); err != nil {
+			return nil, err
+		}
+		if header, err = readFileVecHeader(buf[:]); err != nil {
+			return nil, err
+		}
+		if header.elementSize != uint32(size) {
+			return nil, ErrFileVecElementSize
+		}
+		capacity = int((info.Size() - fileVecHeaderSize) / int64(size))
+		if header.len > uint64(capacity) {
+			return nil, ErrFileVecChecksum
+		}
+	}
+	if capacity == 0 {
+		capacity = minFileVecCapacity
+		if err := file.Truncate(int64(fileVecHeaderSize + capacity*size)); err != nil {
+			return nil, err
+		}
+	}
+	if err := v.mapSegment(0, capacity); err != nil {
+		return nil, err
+	}
+	v.first = capacity
+	v.len, v.seq = int(header.len), header.seq
+	if info.Size() == 0 {
+		if err := v.Sync(); err != nil {
+			v.unmap()
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// mapSegment maps the part of the file with the elements from start to end, the file must have room for them
+func (v *FileVec[T]) mapSegment(start, end int) error {
+	var zero T
+	size := int64(unsafe.Sizeof(zero))
+	from := fileVecHeaderSize + int64(start)*size
+	// the first segment has the header too, the header keeps the elements aligned for any T inside it
+	offset := int64(0)
+	if start > 0 {
+		offset = mapOffset(from)
+	}
+	mem, err := mapFile(v.file, offset, int(fileVecHeaderSize+int64(end)*size-offset))
+	if err != nil {
+		return err
+	}
+	v.segments = append(v.segments, fileVecSegment[T]{
+		offset:   offset,
+		mem:      mem,
+		elements: unsafe.Slice((*T)(unsafe.Pointer(&mem[from-offset])), end-start),
+	})
+	v.capacity = end
+	return nil
+}
+
+// grow doubles the capacity, the file is extended and the new part mapped before anything changes so the
+// FileVec stays usable if it fails
+func (v *FileVec[T]) grow() error {
+	var zero T
+	capacity := 2 * v.capacity
+	if err := v.file.Truncate(fileVecHeaderSize + int64(capacity)*int64(unsafe.Sizeof(zero))); err != nil {
+		return err
+	}
+	return v.mapSegment(v.capacity, capacity)
+}
+
+// segment returns the elements of the segment that has index and the position of index in them
+func (v *FileVec[T]) segment(index int) ([]T, int) {
+	if index < v.first {
+		return v.segments[0].elements, index
+	}
+	s := bits.Len(uint(index / v.first))
+	return v.segments[s].elements, index - v.first<<(s-1)
+}
+
+func (v *FileVec[T]) ref(index int) *T {
+	if index < 0 || index >= v.len {
+		panic("atone: FileVec index out of range")
+	}
+	elements, i := v.segment(index)
+	return &elements[i]
+}
+
+func (v *FileVec[T]) unmap() error {
+	var err error
+	for _, segment := range v.segments {
+		if unmapErr := unmapFile(segment.mem); err == nil {
+			err = unmapErr
+		}
+	}
+	v.segments = nil
+	return err
+}
+
+// Sync flushes the elements to disk and then writes the header, so the header never has a length with
+// elements that were not flushed
+func (v *FileVec[T]) Sync() error {
+	for _, segment := range v.segments {
+		if err := syncFile(v.file, segment.offset, segment.mem); err != nil {
+			return err
+		}
+	}
+	if err := v.file.Sync(); err != nil {
+		return err
+	}
+	var zero T
+	header := fileVecHeader{
+		elementSize: uint32(unsafe.Sizeof(zero)),
+		len:         uint64(v.len),
+		seq:         v.seq + 1,
+	}
+	// the slot of the last Sync is left as it is
+	slot := int(header.seq%2) * fileVecSlotSize
+	mem := v.segments[0].mem
+	header.encode(mem[slot : slot+fileVecSlotSize])
+	if err := syncFile(v.file, 0, mem[:fileVecHeaderSize]); err != nil {
+		return err
+	}
+	if err := v.file.Sync(); err != nil {
+		return err
+	}
+	v.seq = header.seq
+	return nil
+}
+
+// Close syncs and closes the file, the FileVec must not be used after it
+func (v *FileVec[T]) Close() error {
+	err := v.Sync()
+	if unmapErr := v.unmap(); err == nil {
+		err = unmapErr
+	}
+	if closeErr := v.file.Close(); err == nil {
+		err = closeErr
+	}
+	v.capacity, v.len = 0, 0
+	return err
+}
+
+// Len returns the number of elements stored in the array
+func (v *FileVec[T]) Len() int {
+	return v.len
+}
+
+// IsEmpty returns if there is any element in the array or not
+func (v *FileVec[T]) IsEmpty() bool {
+	return v.len == 0
+}
+
+// Capacity returns the number of elements the file has room for
+func (v *FileVec[T]) Capacity() int {
+	return v.capacity
+}
+
+// Get returns the element in the specified index, can panic if it is outofbounds, if you don't want to panic on get, use Lookup
+func (v *FileVec[T]) Get(index int) T {
+	return *v.ref(index)
+}
+
+// Lookup returns an element, the boolean is false if the element does not exist.
+func (v *FileVec[T]) Lookup(index int) (T, bool) {
+	var defaul T
+	if index < 0 || index >= v.len {
+		return defaul, false
+	}
+	return *v.ref(index), true
+}
+
+// GetRef returns a pointer to the element in the file, it's valid until Close
+func (v *FileVec[T]) GetRef(index int) *T {
+	return v.ref(index)
+}
+
+// Set sets the element in the specified index, can panic if it is outofbounds
+func (v *FileVec[T]) Set(index int, el T) {
+	*v.ref(index) = el
+}
+
+// Push pushes back an element into the array, growing the file if it's full
+func (v *FileVec[T]) Push(el T) error {
+	if v.len == v.capacity {
+		if err := v.grow(); err != nil {
+			return err
+		}
+	}
+	v.len++
+	*v.ref(v.len - 1) = el
+	return nil
+}
+
+// PopBack pops the last element of the array, returns null if the array is empty
+func (v *FileVec[T]) PopBack() T {
+	var t T
+	if v.len == 0 {
+		return t
+	}
+	ref := v.ref(v.len - 1)
+	popped := *ref
+	*ref = t
+	v.len--
+	return popped
+}
+
+// Truncate only will mantain only the first 'n' elements in the array
+func (v *FileVec[T]) Truncate(n int) {
+	for i := n; i < v.len; {
+		elements, j := v.segment(i)
+		elements = elements[j:min(len(elements), j+v.len-i)]
+		zeroSlice(elements)
+		i += len(elements)
+	}
+	if n < v.len {
+		v.len = n
+	}
+}
+
+// All returns an iterator over the indexes and elements of the FileVec (from Go 1.23 it can be used with range over func)
+func (v *FileVec[T]) All() func(yield func(index int, el T) bool) {
+	return func(yield func(index int, el T) bool) {
+		for i := 0; i < v.len; {
+			elements, j := v.segment(i)
+			for ; j < len(elements) && i < v.len; i, j = i+1, j+1 {
+				if !yield(i, elements[j]) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Iter generates an array of elements on the Go heap (allocates space for the iteration)
+func (v *FileVec[T]) Iter() []T {
+	elements := make([]T, v.len)
+	for i := 0; i < v.len; {
+		segment, j := v.segment(i)
+		i += copy(elements[i:], segment[j:])
+	}
+	return elements
+}
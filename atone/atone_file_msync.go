@@ -0,0 +1,7 @@
+//go:build linux || darwin || freebsd || openbsd || dragonfly
+
+package atone
+
+import "syscall"
+
+const sysMsync = syscall.SYS_MSYNC
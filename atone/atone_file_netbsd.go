@@ -0,0 +1,4 @@
+package atone
+
+// sysMsync is __msync13, package syscall has no number for msync on netbsd
+const sysMsync = 277
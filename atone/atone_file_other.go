@@ -0,0 +1,36 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package atone
+
+import (
+	"io"
+	"os"
+	"unsafe"
+)
+
+// mapOffset returns pos, every segment is read into its own memory so they must not overlap or Sync would
+// write the stale copy of one segment over the other
+func mapOffset(pos int64) int64 {
+	return pos
+}
+
+// mapFile reads size bytes of the file from offset into memory, aligned for any T
+func mapFile(file *os.File, offset int64, size int) ([]byte, error) {
+	words := make([]uint64, (size+7)/8)
+	mem := unsafe.Slice((*byte)(unsafe.Pointer(&words[0])), size)
+	if _, err := file.ReadAt(mem, offset); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return mem, nil
+}
+
+// unmapFile does nothing, the memory is written back by syncFile
+func unmapFile(mem []byte) error {
+	return nil
+}
+
+// syncFile writes the memory back to the file
+func syncFile(file *os.File, offset int64, mem []byte) error {
+	_, err := file.WriteAt(mem, offset)
+	return err
+}
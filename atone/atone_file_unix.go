@@ -0,0 +1,34 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package atone
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// mapOffset returns where a mapping that starts at pos must start instead, mappings start at a page so the pages
+// before pos that belong to another segment are mapped twice (which is fine, both mappings share the memory)
+func mapOffset(pos int64) int64 {
+	return pos &^ int64(os.Getpagesize()-1)
+}
+
+// mapFile maps size bytes of the file from offset, which must be a multiple of the page size. Changes to the
+// memory go to the file.
+func mapFile(file *os.File, offset int64, size int) ([]byte, error) {
+	return syscall.Mmap(int(file.Fd()), offset, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+}
+
+func unmapFile(mem []byte) error {
+	return syscall.Munmap(mem)
+}
+
+// syncFile writes the changes done through a mapping of the file to disk
+func syncFile(file *os.File, offset int64, mem []byte) error {
+	_, _, errno := syscall.Syscall(sysMsync, uintptr(unsafe.Pointer(&mem[0])), uintptr(len(mem)), syscall.MS_SYNC)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package atone
+
+import (
+	"hash/maphash"
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+// hasher returns a hash function for K that hashes equal keys to the same value. Keys whose memory can be
+// compared byte by byte (integers, pointers, structs of those without padding...) are hashed directly, strings
+// go through maphash and anything else (floats, interfaces, structs with strings...) is walked with reflect.
+func hasher[K comparable]() func(K) uint64 {
+	seed := maphash.MakeSeed()
+	var mixer maphash.Hash
+	mixer.SetSeed(seed)
+	mix := mixer.Sum64()
+
+	var zero K
+	t := reflect.TypeOf(&zero).Elem()
+	size := t.Size()
+	switch {
+	case memHashable(t) && size <= 8:
+		return func(k K) uint64 {
+			var word uint64
+			copy((*[8]byte)(unsafe.Pointer(&word))[:size], unsafe.Slice((*byte)(unsafe.Pointer(&k)), size))
+			return mix64(word ^ mix)
+		}
+	case memHashable(t):
+		return func(k K) uint64 {
+			var h maphash.Hash
+			h.SetSeed(seed)
+			h.Write(unsafe.Slice((*byte)(unsafe.Pointer(&k)), size))
+			return h.Sum64()
+		}
+	case t.Kind() == reflect.String:
+		return func(k K) uint64 {
+			var h maphash.Hash
+			h.SetSeed(seed)
+			h.WriteString(*(*string)(unsafe.Pointer(&k)))
+			return h.Sum64()
+		}
+	}
+	return func(k K) uint64 {
+		var h maphash.Hash
+		h.SetSeed(seed)
+		hashValue(&h, reflect.ValueOf(&k).Elem())
+		return h.Sum64()
+	}
+}
+
+// memHashable returns true if two values of t are equal only when their memory is equal
+func memHashable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Ptr, reflect.UnsafePointer, reflect.Chan:
+		return true
+	case reflect.Array:
+		return memHashable(t.Elem())
+	case reflect.Struct:
+		size := uintptr(0)
+		for i := 0; i < t.NumField(); i++ {
+			if !memHashable(t.Field(i).Type) {
+				return false
+			}
+			size += t.Field(i).Type.Size()
+		}
+		// padding bytes don't take part in ==
+		return size == t.Size()
+	}
+	return false
+}
+
+func hashValue(h *maphash.Hash, v reflect.Value) {
+	var buf [8]byte
+	writeUint := func(n uint64) {
+		for i := range buf {
+			buf[i] = byte(n >> (8 * i))
+		}
+		h.Write(buf[:])
+	}
+	writeFloat := func(f float64) {
+		if f == 0 {
+			// +0 == -0
+			f = 0
+		}
+		writeUint(math.Float64bits(f))
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			writeUint(1)
+		} else {
+			writeUint(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeFloat(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		writeFloat(real(v.Complex()))
+		writeFloat(imag(v.Complex()))
+	case reflect.String:
+		h.WriteString(v.String())
+	case reflect.Ptr, reflect.UnsafePointer, reflect.Chan:
+		writeUint(uint64(v.Pointer()))
+	case reflect.Interface:
+		if v.IsNil() {
+			writeUint(0)
+			return
+		}
+		h.WriteString(v.Elem().Type().String())
+		hashValue(h, v.Elem())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			hashValue(h, v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			hashValue(h, v.Field(i))
+		}
+	default:
+		panic("atone: can't hash a value of kind " + v.Kind().String())
+	}
+}
+
+// mix64 is the splitmix64 finalizer
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
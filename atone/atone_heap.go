@@ -0,0 +1,276 @@
+package atone
+
+// Heap is a binary heap (a priority queue) backed by a Vec, so it grows without resize spikes.
+// The element on top is the one that is less than the rest of them according to less.
+type Heap[T any] struct {
+	vec  *Vec[T]
+	less func(a, b T) bool
+}
+
+// NewHeap returns a new Heap ordered by less
+func NewHeap[T any](less func(a, b T) bool) *Heap[T] {
+	return &Heap[T]{vec: New[T](), less: less}
+}
+
+// HeapFrom returns a new Heap with the elements of the slice, it takes ownership of the slice
+func HeapFrom[T any](elements []T, less func(a, b T) bool) *Heap[T] {
+	h := &Heap[T]{vec: From(elements), less: less}
+	n := h.Len()
+	for i := n/2 - 1; i >= 0; i-- {
+		siftDown(h, i, n)
+	}
+	return h
+}
+
+// heapOps is what siftUp and siftDown need from a heap
+type heapOps interface {
+	lessAt(i, j int) bool
+	swapAt(i, j int)
+}
+
+func siftUp[H heapOps](h H, i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !h.lessAt(i, parent) {
+			return
+		}
+		h.swapAt(i, parent)
+		i = parent
+	}
+}
+
+// siftDown returns true if the element moved
+func siftDown[H heapOps](h H, i, n int) bool {
+	start := i
+	for {
+		child := 2*i + 1
+		if child >= n || child < 0 {
+			break
+		}
+		if right := child + 1; right < n && h.lessAt(right, child) {
+			child = right
+		}
+		if !h.lessAt(child, i) {
+			break
+		}
+		h.swapAt(i, child)
+		i = child
+	}
+	return i > start
+}
+
+func (h *Heap[T]) lessAt(i, j int) bool {
+	return h.less(h.vec.Get(i), h.vec.Get(j))
+}
+
+func (h *Heap[T]) swapAt(i, j int) {
+	h.vec.Swap(i, j)
+}
+
+// Len returns the number of elements in the heap
+func (h *Heap[T]) Len() int {
+	return h.vec.Len()
+}
+
+// IsEmpty returns if there is any element in the heap or not
+func (h *Heap[T]) IsEmpty() bool {
+	return h.vec.IsEmpty()
+}
+
+// Push pushes an element into the heap
+func (h *Heap[T]) Push(el T) {
+	h.vec.Push(el)
+	siftUp(h, h.Len()-1)
+}
+
+// Peek returns the top of the heap without popping it, returns null if the heap is empty
+func (h *Heap[T]) Peek() T {
+	return h.vec.First()
+}
+
+// Pop pops the top of the heap, returns null if the heap is empty
+func (h *Heap[T]) Pop() T {
+	n := h.Len() - 1
+	if n < 0 {
+		var t T
+		return t
+	}
+	h.vec.Swap(0, n)
+	siftDown(h, 0, n)
+	return h.vec.PopBack()
+}
+
+// Get returns the element in the specified position of the heap, position 0 is the top
+func (h *Heap[T]) Get(i int) T {
+	return h.vec.Get(i)
+}
+
+// Fix restores the heap ordering after the element in position i has changed (e.g. through Update), it's
+// cheaper than doing Remove(i) and then Push
+func (h *Heap[T]) Fix(i int) {
+	if !siftDown(h, i, h.Len()) {
+		siftUp(h, i)
+	}
+}
+
+// Update sets the element in position i and restores the heap ordering
+func (h *Heap[T]) Update(i int, el T) {
+	h.vec.Set(i, el)
+	h.Fix(i)
+}
+
+// Remove removes and returns the element in position i, it panics if there is no element in that position
+func (h *Heap[T]) Remove(i int) T {
+	n := h.Len() - 1
+	if i < 0 || i > n {
+		panic("atone: heap position out of range")
+	}
+	if n != i {
+		h.vec.Swap(i, n)
+		if !siftDown(h, i, n) {
+			siftUp(h, i)
+		}
+	}
+	return h.vec.PopBack()
+}
+
+// Iter generates an array with the elements of the heap in heap order (allocates space for the iteration)
+func (h *Heap[T]) Iter() []T {
+	return h.vec.Iter()
+}
+
+// IndexedHeap is a Heap where every pushed element gets an id that can be used to update (decrease-key) or
+// remove it later on, wherever it is in the heap. Ids of removed elements are reused.
+type IndexedHeap[T any] struct {
+	// heap has the ids ordered as a heap
+	heap *Vec[int]
+	// values has the element of each id
+	values *Vec[T]
+	// positions has the position in heap of each id, -1 if the id is not in the heap
+	positions *Vec[int]
+	// free has the ids that can be reused
+	free *Vec[int]
+	less func(a, b T) bool
+}
+
+// NewIndexedHeap returns a new IndexedHeap ordered by less
+func NewIndexedHeap[T any](less func(a, b T) bool) *IndexedHeap[T] {
+	return &IndexedHeap[T]{
+		heap:      New[int](),
+		values:    New[T](),
+		positions: New[int](),
+		free:      New[int](),
+		less:      less,
+	}
+}
+
+func (h *IndexedHeap[T]) lessAt(i, j int) bool {
+	return h.less(h.values.Get(h.heap.Get(i)), h.values.Get(h.heap.Get(j)))
+}
+
+func (h *IndexedHeap[T]) swapAt(i, j int) {
+	h.heap.Swap(i, j)
+	h.positions.Set(h.heap.Get(i), i)
+	h.positions.Set(h.heap.Get(j), j)
+}
+
+// Len returns the number of elements in the heap
+func (h *IndexedHeap[T]) Len() int {
+	return h.heap.Len()
+}
+
+// IsEmpty returns if there is any element in the heap or not
+func (h *IndexedHeap[T]) IsEmpty() bool {
+	return h.heap.IsEmpty()
+}
+
+// Push pushes an element into the heap and returns its id
+func (h *IndexedHeap[T]) Push(el T) int {
+	var id int
+	if h.free.IsEmpty() {
+		id = h.values.Len()
+		h.values.Push(el)
+		h.positions.Push(h.heap.Len())
+	} else {
+		id = h.free.PopBack()
+		h.values.Set(id, el)
+		h.positions.Set(id, h.heap.Len())
+	}
+	h.heap.Push(id)
+	siftUp(h, h.heap.Len()-1)
+	return id
+}
+
+// Contains returns true if the id is in the heap
+func (h *IndexedHeap[T]) Contains(id int) bool {
+	if id < 0 {
+		return false
+	}
+	position, ok := h.positions.Lookup(id)
+	return ok && position >= 0
+}
+
+// Get returns the element of the id, the boolean is false if the id is not in the heap
+func (h *IndexedHeap[T]) Get(id int) (T, bool) {
+	var defaul T
+	if !h.Contains(id) {
+		return defaul, false
+	}
+	return h.values.Get(id), true
+}
+
+// Peek returns the top of the heap and its id without popping it, the id is -1 if the heap is empty
+func (h *IndexedHeap[T]) Peek() (T, int) {
+	var defaul T
+	if h.IsEmpty() {
+		return defaul, -1
+	}
+	id := h.heap.First()
+	return h.values.Get(id), id
+}
+
+// Pop pops the top of the heap and returns it with its id, the id is -1 if the heap is empty
+func (h *IndexedHeap[T]) Pop() (T, int) {
+	var defaul T
+	if h.IsEmpty() {
+		return defaul, -1
+	}
+	id := h.heap.First()
+	return h.Remove(id), id
+}
+
+// Update changes the element of the id and restores the heap ordering, whether the new element goes up
+// (decrease-key) or down. It panics if the id is not in the heap.
+func (h *IndexedHeap[T]) Update(id int, el T) {
+	if !h.Contains(id) {
+		panic("atone: id is not in the heap")
+	}
+	h.values.Set(id, el)
+	position := h.positions.Get(id)
+	if !siftDown(h, position, h.heap.Len()) {
+		siftUp(h, position)
+	}
+}
+
+// Remove removes the element of the id and returns it, returns null if the id is not in the heap
+func (h *IndexedHeap[T]) Remove(id int) T {
+	var defaul T
+	if !h.Contains(id) {
+		return defaul
+	}
+	position := h.positions.Get(id)
+	n := h.heap.Len() - 1
+	if position != n {
+		h.swapAt(position, n)
+		if !siftDown(h, position, n) {
+			siftUp(h, position)
+		}
+	}
+	h.heap.PopBack()
+	el := h.values.Get(id)
+	// release the element so it can be collected
+	h.values.Set(id, defaul)
+	h.positions.Set(id, -1)
+	h.free.Push(id)
+	return el
+}
@@ -0,0 +1,56 @@
+package atone
+
+import (
+	"math"
+	"strings"
+)
+
+// Interner gives every distinct string a small id, the ids are given in order starting from 0 and Get turns
+// them back into their strings.
+type Interner struct {
+	ids     *Map[string, uint32]
+	strings *Vec[string]
+}
+
+// NewInterner returns a new empty Interner
+func NewInterner() *Interner {
+	return &Interner{ids: NewMap[string, uint32](), strings: New[string]()}
+}
+
+// Intern returns the id of s, giving it the next id if it wasn't interned yet. The Interner keeps its own copy of s,
+// so s can point to a bigger buffer that will be freed.
+func (in *Interner) Intern(s string) uint32 {
+	if id, ok := in.ids.Get(s); ok {
+		return id
+	}
+	if uint64(in.strings.Len()) > math.MaxUint32 {
+		panic("atone: too many interned strings")
+	}
+	var clone strings.Builder
+	clone.WriteString(s)
+	s = clone.String()
+	id := uint32(in.strings.Len())
+	in.strings.Push(s)
+	in.ids.Set(s, id)
+	return id
+}
+
+// ID returns the id of s without interning it, the boolean is false if it wasn't interned
+func (in *Interner) ID(s string) (uint32, bool) {
+	return in.ids.Get(s)
+}
+
+// Lookup returns the string of the id, the boolean is false if there is no string with that id
+func (in *Interner) Lookup(id uint32) (string, bool) {
+	return in.strings.Lookup(int(id))
+}
+
+// Get returns the string of the id, can panic if there is no string with that id, if you don't want to panic use Lookup
+func (in *Interner) Get(id uint32) string {
+	return in.strings.Get(int(id))
+}
+
+// Len returns the number of interned strings
+func (in *Interner) Len() int {
+	return in.strings.Len()
+}
@@ -0,0 +1,94 @@
+package atone
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// ErrVecJSON is returned by DecodeJSON and UnmarshalJSON when the JSON value is not an array
+var ErrVecJSON = errors.New("atone: a Vec must be decoded from a JSON array")
+
+// MarshalJSON encodes the Vec as a JSON array (it implements json.Marshaler)
+func (v *Vec[T]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := v.EncodeJSON(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON replaces the elements of the Vec with the ones of a JSON array, null leaves it as it is
+// (it implements json.Unmarshaler)
+func (v *Vec[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil
+	}
+	return v.DecodeJSON(bytes.NewReader(data))
+}
+
+// EncodeJSON writes the Vec to w as a JSON array, encoding one element at a time instead of building the
+// whole array in memory
+func (v *Vec[T]) EncodeJSON(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if err := bw.WriteByte('['); err != nil {
+		return err
+	}
+	first := true
+	for _, segment := range [2][]T{v.oldHead[:v.oldLen()], v.newTail} {
+		for i := range segment {
+			b, err := json.Marshal(segment[i])
+			if err != nil {
+				return err
+			}
+			if !first {
+				if err := bw.WriteByte(','); err != nil {
+					return err
+				}
+			}
+			first = false
+			// stop at the first error of w instead of encoding the rest of the elements for nothing
+			if _, err := bw.Write(b); err != nil {
+				return err
+			}
+		}
+	}
+	if err := bw.WriteByte(']'); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// DecodeJSON replaces the elements of the Vec with the ones of the JSON array read from r, they are decoded
+// one at a time and pushed like Push does. If it fails the Vec is left empty.
+func (v *Vec[T]) DecodeJSON(r io.Reader) error {
+	v.Clear()
+	dec := json.NewDecoder(r)
+	if err := v.decodeJSON(dec); err != nil {
+		v.Clear()
+		return err
+	}
+	return nil
+}
+
+func (v *Vec[T]) decodeJSON(dec *json.Decoder) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != json.Delim('[') {
+		return ErrVecJSON
+	}
+	for dec.More() {
+		var el T
+		if err := dec.Decode(&el); err != nil {
+			return err
+		}
+		v.Push(el)
+	}
+	// the closing ]
+	_, err = dec.Token()
+	return err
+}
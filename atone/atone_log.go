@@ -0,0 +1,115 @@
+package atone
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"unsafe"
+)
+
+// AppendLog is an append only log where many goroutines can Append at the same time, each append
+// reserves a stable index with a single atomic add. Elements are never moved: the log grows by adding
+// segments, each one twice as big as the previous one, so there is nothing to carry and no writer ever waits
+// for a copy. Get doesn't take any lock.
+type AppendLog[T any] struct {
+	// reserved is the number of indexes handed out to writers
+	reserved uint64
+	// firstBits is log2 of the size of the first segment
+	firstBits uint
+	// segments[k] is a *logSegment[T] with 1<<(firstBits+k) elements, allocated on demand
+	segments [maxLogSegments]unsafe.Pointer
+}
+
+// maxLogSegments is enough segments to address every uint64 index
+const maxLogSegments = 64
+
+// defaultLogSegment is the size of the first segment of an AppendLog created with NewAppendLog
+const defaultLogSegment = 64
+
+type logSegment[T any] struct {
+	elements []T
+	// published[i] is 1 once elements[i] has been written
+	published []uint32
+}
+
+// NewAppendLog returns a new AppendLog
+func NewAppendLog[T any]() *AppendLog[T] {
+	return NewAppendLogWithCapacity[T](defaultLogSegment)
+}
+
+// NewAppendLogWithCapacity returns a new AppendLog whose first segment fits capacity elements (rounded
+// up to a power of two)
+func NewAppendLogWithCapacity[T any](capacity uint64) *AppendLog[T] {
+	firstBits := uint(0)
+	if capacity > 1 {
+		firstBits = uint(bits.Len64(capacity - 1))
+	}
+	return &AppendLog[T]{firstBits: firstBits}
+}
+
+// locate returns the segment number and the offset inside it of index
+func (l *AppendLog[T]) locate(index uint64) (int, uint64) {
+	// shift the index by the first segment size so the segments become powers of two
+	shifted := index + 1<<l.firstBits
+	segment := bits.Len64(shifted) - 1 - int(l.firstBits)
+	return segment, shifted - 1<<(uint(segment)+l.firstBits)
+}
+
+func (l *AppendLog[T]) loadSegment(segment int) *logSegment[T] {
+	return (*logSegment[T])(atomic.LoadPointer(&l.segments[segment]))
+}
+
+func (l *AppendLog[T]) segment(segment int) *logSegment[T] {
+	if s := l.loadSegment(segment); s != nil {
+		return s
+	}
+	size := uint64(1) << (uint(segment) + l.firstBits)
+	s := &logSegment[T]{elements: make([]T, size), published: make([]uint32, size)}
+	if atomic.CompareAndSwapPointer(&l.segments[segment], nil, unsafe.Pointer(s)) {
+		return s
+	}
+	// another writer allocated it first
+	return l.loadSegment(segment)
+}
+
+// Append appends an element and returns its index, which is stable for the lifetime of the log
+func (l *AppendLog[T]) Append(el T) int {
+	index := atomic.AddUint64(&l.reserved, 1) - 1
+	segment, offset := l.locate(index)
+	s := l.segment(segment)
+	s.elements[offset] = el
+	atomic.StoreUint32(&s.published[offset], 1)
+	return int(index)
+}
+
+// Get returns the element in the specified index, the boolean is false if the index is out of bounds or
+// its writer hasn't finished publishing it yet
+func (l *AppendLog[T]) Get(index int) (T, bool) {
+	var defaul T
+	if index < 0 || uint64(index) >= atomic.LoadUint64(&l.reserved) {
+		return defaul, false
+	}
+	segment, offset := l.locate(uint64(index))
+	s := l.loadSegment(segment)
+	if s == nil || atomic.LoadUint32(&s.published[offset]) == 0 {
+		return defaul, false
+	}
+	return s.elements[offset], true
+}
+
+// Len returns the number of indexes handed out, some of them might not be published yet
+func (l *AppendLog[T]) Len() int {
+	return int(atomic.LoadUint64(&l.reserved))
+}
+
+// ForEach iterates through the published elements in order, stopping at the first index that hasn't been
+// published yet so fn always sees a prefix of the log without holes
+func (l *AppendLog[T]) ForEach(fn func(el T, index int)) {
+	n := l.Len()
+	for i := 0; i < n; i++ {
+		el, ok := l.Get(i)
+		if !ok {
+			return
+		}
+		fn(el, i)
+	}
+}
@@ -0,0 +1,186 @@
+package atone
+
+// LRU is a least recently used cache with a capacity limit. The recency list is made of nodes linked by their
+// index in a Vec instead of pointers, so the GC has much fewer pointers to scan than with a container/list
+// based cache, and the nodes of removed keys are reused.
+type LRU[K comparable, V any] struct {
+	index *Map[K, int]
+	nodes *Vec[lruNode[K, V]]
+	// head is the most recently used node, tail the least recently used one, free the first removed node
+	// that can be reused (linked through next)
+	head, tail, free int
+	capacity         int
+	onEvict          func(key K, value V)
+}
+
+type lruNode[K comparable, V any] struct {
+	key        K
+	value      V
+	prev, next int
+}
+
+// lruNil is the index used as a nil link
+const lruNil = -1
+
+// NewLRU returns a new LRU that holds up to capacity keys, onEvict (which can be nil) is called with
+// every key that is evicted to make room for a new one
+func NewLRU[K comparable, V any](capacity int, onEvict func(key K, value V)) *LRU[K, V] {
+	if capacity <= 0 {
+		panic("atone: LRU capacity must be positive")
+	}
+	return &LRU[K, V]{
+		index:    NewMap[K, int](),
+		nodes:    New[lruNode[K, V]](),
+		head:     lruNil,
+		tail:     lruNil,
+		free:     lruNil,
+		capacity: capacity,
+		onEvict:  onEvict,
+	}
+}
+
+func (l *LRU[K, V]) node(i int) *lruNode[K, V] {
+	return l.nodes.GetRef(i)
+}
+
+func (l *LRU[K, V]) unlink(i int) {
+	n := l.node(i)
+	if n.prev != lruNil {
+		l.node(n.prev).next = n.next
+	} else {
+		l.head = n.next
+	}
+	if n.next != lruNil {
+		l.node(n.next).prev = n.prev
+	} else {
+		l.tail = n.prev
+	}
+}
+
+func (l *LRU[K, V]) pushFront(i int) {
+	n := l.node(i)
+	n.prev = lruNil
+	n.next = l.head
+	if l.head != lruNil {
+		l.node(l.head).prev = i
+	} else {
+		l.tail = i
+	}
+	l.head = i
+}
+
+// Len returns the number of keys in the cache
+func (l *LRU[K, V]) Len() int {
+	return l.index.Len()
+}
+
+// Cap returns the max number of keys in the cache
+func (l *LRU[K, V]) Cap() int {
+	return l.capacity
+}
+
+// Get returns the value of key and marks it as the most recently used one, the boolean is false if it's not in the cache
+func (l *LRU[K, V]) Get(key K) (V, bool) {
+	var defaul V
+	i, ok := l.index.Get(key)
+	if !ok {
+		return defaul, false
+	}
+	if i != l.head {
+		l.unlink(i)
+		l.pushFront(i)
+	}
+	return l.node(i).value, true
+}
+
+// Peek returns the value of key without changing its recency, the boolean is false if it's not in the cache
+func (l *LRU[K, V]) Peek(key K) (V, bool) {
+	var defaul V
+	i, ok := l.index.Get(key)
+	if !ok {
+		return defaul, false
+	}
+	return l.node(i).value, true
+}
+
+// Contains returns true if key is in the cache without changing its recency
+func (l *LRU[K, V]) Contains(key K) bool {
+	return l.index.Has(key)
+}
+
+// Put sets the value of key and marks it as the most recently used one, returns true if the least recently used
+// key was evicted to make room for it
+func (l *LRU[K, V]) Put(key K, value V) bool {
+	if i, ok := l.index.Get(key); ok {
+		l.node(i).value = value
+		if i != l.head {
+			l.unlink(i)
+			l.pushFront(i)
+		}
+		return false
+	}
+	evicted := false
+	var evictedKey K
+	var evictedValue V
+	var i int
+	switch {
+	case l.index.Len() >= l.capacity:
+		// reuse the node of the least recently used key
+		i = l.tail
+		n := l.node(i)
+		evictedKey, evictedValue = n.key, n.value
+		l.unlink(i)
+		l.index.Delete(evictedKey)
+		n.key, n.value = key, value
+		evicted = true
+	case l.free != lruNil:
+		i = l.free
+		n := l.node(i)
+		l.free = n.next
+		n.key, n.value = key, value
+	default:
+		i = l.nodes.Len()
+		l.nodes.Push(lruNode[K, V]{key: key, value: value})
+	}
+	l.pushFront(i)
+	l.index.Set(key, i)
+	// the callback runs once the cache is consistent again, so it can use it
+	if evicted && l.onEvict != nil {
+		l.onEvict(evictedKey, evictedValue)
+	}
+	return evicted
+}
+
+// Remove removes key from the cache, returns false if it wasn't in the cache
+func (l *LRU[K, V]) Remove(key K) bool {
+	i, ok := l.index.Get(key)
+	if !ok {
+		return false
+	}
+	l.index.Delete(key)
+	l.unlink(i)
+	// release the key and the value and keep the node for the next Put
+	*l.node(i) = lruNode[K, V]{next: l.free, prev: lruNil}
+	l.free = i
+	return true
+}
+
+// Oldest returns the least recently used key and its value, the boolean is false if the cache is empty
+func (l *LRU[K, V]) Oldest() (K, V, bool) {
+	var key K
+	var value V
+	if l.tail == lruNil {
+		return key, value, false
+	}
+	n := l.node(l.tail)
+	return n.key, n.value, true
+}
+
+// ForEach iterates from the most recently used key to the least recently used one without changing their recency
+func (l *LRU[K, V]) ForEach(fn func(key K, value V)) {
+	for i := l.head; i != lruNil; {
+		n := l.node(i)
+		fn(n.key, n.value)
+		i = n.next
+	}
+}
@@ -0,0 +1,259 @@
+package atone
+
+// Map is a hash map that grows the atone way (the HashMap counterpart is griddle, https://github.com/jonhoo/griddle).
+// When the table needs to grow the old table is kept around and every insert moves a few of its entries to the
+// new one, so no single Set has to rehash the whole map. Lookups look in both tables until the old one is empty.
+// The zero value is an empty map ready to use.
+type Map[K comparable, V any] struct {
+	hash  func(K) uint64
+	table mapTable[K, V]
+	// old is the table we are moving entries from, nil if we are not growing
+	old *mapTable[K, V]
+	// moved is the index of the first entry of old that hasn't been moved yet
+	moved int
+}
+
+type mapEntry[K comparable, V any] struct {
+	hash  uint64
+	key   K
+	value V
+	state uint8
+}
+
+const (
+	entryEmpty uint8 = iota
+	entryUsed
+	// entryDeleted keeps the probe sequences of other keys going through this entry
+	entryDeleted
+)
+
+// mapTable is an open addressing table with linear probing
+type mapTable[K comparable, V any] struct {
+	entries []mapEntry[K, V]
+	// len is the number of used entries
+	len int
+	// deleted is the number of deleted entries
+	deleted int
+}
+
+// minMapCapacity is the number of entries of the first table
+const minMapCapacity = 8
+
+// NewMap returns a new atone Map
+func NewMap[K comparable, V any]() *Map[K, V] {
+	return NewMapWithCapacity[K, V](0)
+}
+
+// NewMapWithCapacity returns a new atone Map that fits capacity keys without growing
+func NewMapWithCapacity[K comparable, V any](capacity int) *Map[K, V] {
+	size := minMapCapacity
+	for size-size/8 < capacity {
+		size *= 2
+	}
+	return &Map[K, V]{
+		hash:  hasher[K](),
+		table: mapTable[K, V]{entries: make([]mapEntry[K, V], size)},
+	}
+}
+
+// find returns the index of the entry of key, or -1
+func (t *mapTable[K, V]) find(hash uint64, key K) int {
+	mask := len(t.entries) - 1
+	for i := int(hash) & mask; ; i = (i + 1) & mask {
+		e := &t.entries[i]
+		if e.state == entryEmpty {
+			return -1
+		}
+		if e.state == entryUsed && e.hash == hash && e.key == key {
+			return i
+		}
+	}
+}
+
+// insert inserts a key that is not in the table
+func (t *mapTable[K, V]) insert(hash uint64, key K, value V) {
+	mask := len(t.entries) - 1
+	i := int(hash) & mask
+	for t.entries[i].state == entryUsed {
+		i = (i + 1) & mask
+	}
+	if t.entries[i].state == entryDeleted {
+		t.deleted--
+	}
+	t.entries[i] = mapEntry[K, V]{hash: hash, key: key, value: value, state: entryUsed}
+	t.len++
+}
+
+func (t *mapTable[K, V]) remove(i int) {
+	t.entries[i] = mapEntry[K, V]{state: entryDeleted}
+	t.len--
+	t.deleted++
+}
+
+// full returns true if inserting one more key would go over the max load factor (7/8)
+func (t *mapTable[K, V]) full() bool {
+	return t.len+t.deleted+1 > len(t.entries)-len(t.entries)/8
+}
+
+// init sets up a zero value Map
+func (m *Map[K, V]) init() {
+	if m.hash == nil {
+		m.hash = hasher[K]()
+	}
+	if len(m.table.entries) == 0 {
+		m.table = mapTable[K, V]{entries: make([]mapEntry[K, V], minMapCapacity)}
+	}
+}
+
+// Get returns the value of key, the boolean is false if the key is not in the map
+func (m *Map[K, V]) Get(key K) (V, bool) {
+	var defaul V
+	if m.Len() == 0 {
+		return defaul, false
+	}
+	hash := m.hash(key)
+	if i := m.table.find(hash, key); i >= 0 {
+		return m.table.entries[i].value, true
+	}
+	if m.old != nil {
+		if i := m.old.find(hash, key); i >= 0 {
+			return m.old.entries[i].value, true
+		}
+	}
+	return defaul, false
+}
+
+// Has returns true if the key is in the map
+func (m *Map[K, V]) Has(key K) bool {
+	_, ok := m.Get(key)
+	return ok
+}
+
+// Set sets the value of key, inserting it if it's not in the map
+func (m *Map[K, V]) Set(key K, value V) {
+	m.init()
+	hash := m.hash(key)
+	if i := m.table.find(hash, key); i >= 0 {
+		m.table.entries[i].value = value
+		return
+	}
+	if m.old != nil {
+		if i := m.old.find(hash, key); i >= 0 {
+			m.old.entries[i].value = value
+			return
+		}
+	}
+	if m.table.full() {
+		m.grow()
+	}
+	m.table.insert(hash, key, value)
+	if m.old != nil {
+		m.carry(NItemsToMoveOnEachInsert)
+	}
+}
+
+// Delete removes key from the map, returns false if it wasn't in the map
+func (m *Map[K, V]) Delete(key K) bool {
+	if m.Len() == 0 {
+		return false
+	}
+	hash := m.hash(key)
+	if i := m.table.find(hash, key); i >= 0 {
+		m.table.remove(i)
+		return true
+	}
+	if m.old != nil {
+		if i := m.old.find(hash, key); i >= 0 {
+			m.old.remove(i)
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of keys in the map
+func (m *Map[K, V]) Len() int {
+	if m.old != nil {
+		return m.table.len + m.old.len
+	}
+	return m.table.len
+}
+
+// IsEmpty returns if there is any key in the map or not
+func (m *Map[K, V]) IsEmpty() bool {
+	return m.Len() == 0
+}
+
+// Clear empties the map, keeping the current table
+func (m *Map[K, V]) Clear() {
+	m.old = nil
+	m.moved = 0
+	m.table = mapTable[K, V]{entries: make([]mapEntry[K, V], len(m.table.entries))}
+}
+
+// ForEach iterates through the map doing a callback to the passed function, the map must not be changed meanwhile
+func (m *Map[K, V]) ForEach(fn func(key K, value V)) {
+	m.All()(func(key K, value V) bool {
+		fn(key, value)
+		return true
+	})
+}
+
+// All returns an iterator over the keys and values of the map (from Go 1.23 it can be used with range over func),
+// the map must not be changed meanwhile
+func (m *Map[K, V]) All() func(yield func(key K, value V) bool) {
+	return func(yield func(key K, value V) bool) {
+		if m.old != nil {
+			for i := m.moved; i < len(m.old.entries); i++ {
+				if e := &m.old.entries[i]; e.state == entryUsed && !yield(e.key, e.value) {
+					return
+				}
+			}
+		}
+		for i := range m.table.entries {
+			if e := &m.table.entries[i]; e.state == entryUsed && !yield(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// carry moves up to n entries from the old table to the new one
+func (m *Map[K, V]) carry(n int) {
+	for ; m.moved < len(m.old.entries) && n > 0; m.moved++ {
+		e := &m.old.entries[m.moved]
+		if e.state != entryUsed {
+			continue
+		}
+		m.table.insert(e.hash, e.key, e.value)
+		// release the references in the old table, the entry stays deleted so the probes of the rest of keys keep working
+		m.old.remove(m.moved)
+		n--
+	}
+	if m.moved == len(m.old.entries) {
+		m.old = nil
+		m.moved = 0
+	}
+}
+
+func (m *Map[K, V]) carryAll() {
+	if m.old != nil {
+		m.carry(len(m.old.entries))
+	}
+}
+
+func (m *Map[K, V]) grow() {
+	// if we are still moving the previous table, finish it before starting with the next one
+	m.carryAll()
+	// The new table must fit every key of the current one plus the keys inserted while we move them (one every
+	// NItemsToMoveOnEachInsert moves) without growing again. Doubling is enough for that, and if most of the
+	// table are deleted entries a table of the same size is enough too.
+	size := len(m.table.entries)
+	if m.table.len >= size/4 {
+		size *= 2
+	}
+	old := m.table
+	m.old = &old
+	m.moved = 0
+	m.table = mapTable[K, V]{entries: make([]mapEntry[K, V], size)}
+}
@@ -8,3 +8,236 @@
 /// atone_mmm has no GC overhead and you must _manually free it_.
 
 package atone
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// ManualVec is a Vec whose elements live outside of the Go heap (in memory that is mmap'ed on unix), so the GC
+// never scans nor moves them. T must not contain pointers, and Free must be called once the ManualVec is not
+// needed anymore. Build with the atonedebug tag to get a report of the ManualVecs that are collected without
+// being freed.
+//
+// It grows like Vec: the old region is kept while every push copies NItemsToMoveOnEachInsert elements from its
+// end to the new region, and it's unmapped once it's empty. Every element is kept at its own index in both regions,
+// the elements before moved are in the old region and the rest of them in the new one.
+type ManualVec[T any] struct {
+	old, new       []T
+	oldMem, newMem []byte
+	len            int
+	// moved is the number of elements that are still in the old region
+	moved int
+}
+
+var _ Sequence[int] = (*ManualVec[int])(nil)
+
+// NewManualVec returns a new ManualVec, it panics if T contains pointers
+func NewManualVec[T any]() *ManualVec[T] {
+	return NewManualVecWithCapacity[T](0)
+}
+
+// NewManualVecWithCapacity returns a new ManualVec that fits capacity elements without growing, it panics if T contains pointers
+func NewManualVecWithCapacity[T any](capacity int) *ManualVec[T] {
+	var zero T
+	if hasPointers(reflect.TypeOf(&zero).Elem()) {
+		panic("atone: ManualVec elements can't contain pointers")
+	}
+	v := &ManualVec[T]{}
+	if capacity > 0 {
+		v.new, v.newMem = manualAlloc[T](capacity)
+	}
+	trackManualVec(v)
+	return v
+}
+
+// hasPointers returns true if values of t contain pointers the GC has to know about
+func hasPointers(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Array:
+		return t.Len() > 0 && hasPointers(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if hasPointers(t.Field(i).Type) {
+				return true
+			}
+		}
+		return false
+	case reflect.Ptr, reflect.UnsafePointer, reflect.Map, reflect.Slice, reflect.String, reflect.Interface,
+		reflect.Func, reflect.Chan:
+		return true
+	}
+	return false
+}
+
+func manualAlloc[T any](capacity int) ([]T, []byte) {
+	var zero T
+	size := int(unsafe.Sizeof(zero))
+	if size == 0 {
+		return make([]T, capacity), nil
+	}
+	mem := allocManualMemory(size * capacity)
+	return unsafe.Slice((*T)(unsafe.Pointer(&mem[0])), capacity), mem
+}
+
+// allocated returns true if the ManualVec holds memory that must be freed
+func (v *ManualVec[T]) allocated() bool {
+	return v.oldMem != nil || v.newMem != nil
+}
+
+// Free releases the memory of the ManualVec, it's empty afterwards. Pointers returned by GetRef must not be used after it.
+func (v *ManualVec[T]) Free() {
+	v.freeOld()
+	if v.newMem != nil {
+		freeManualMemory(v.newMem)
+	}
+	v.new, v.newMem = nil, nil
+	v.len = 0
+}
+
+func (v *ManualVec[T]) freeOld() {
+	if v.oldMem != nil {
+		freeManualMemory(v.oldMem)
+	}
+	v.old, v.oldMem = nil, nil
+	v.moved = 0
+}
+
+// Len returns the number of elements stored in the array
+func (v *ManualVec[T]) Len() int {
+	return v.len
+}
+
+// IsEmpty returns if there is any element in the array or not
+func (v *ManualVec[T]) IsEmpty() bool {
+	return v.len == 0
+}
+
+// Capacity returns the number of elements the ManualVec can hold without growing
+func (v *ManualVec[T]) Capacity() int {
+	return cap(v.new)
+}
+
+func (v *ManualVec[T]) ref(index int) *T {
+	if index < 0 || index >= v.len {
+		panic("atone: ManualVec index out of range")
+	}
+	if index < v.moved {
+		return &v.old[index]
+	}
+	return &v.new[index]
+}
+
+// Get returns the element in the specified index, can panic if it is outofbounds, if you don't want to panic on get, use Lookup
+func (v *ManualVec[T]) Get(index int) T {
+	return *v.ref(index)
+}
+
+// Lookup returns an element, the boolean is false if the element does not exist.
+func (v *ManualVec[T]) Lookup(index int) (T, bool) {
+	var defaul T
+	if index < 0 || index >= v.len {
+		return defaul, false
+	}
+	return *v.ref(index), true
+}
+
+// GetRef returns a pointer to the element, it's valid until the next Push or Free
+func (v *ManualVec[T]) GetRef(index int) *T {
+	return v.ref(index)
+}
+
+// Set sets the element in the specified index, can panic if it is outofbounds
+func (v *ManualVec[T]) Set(index int, el T) {
+	*v.ref(index) = el
+}
+
+// Push pushes back an element into the array
+func (v *ManualVec[T]) Push(el T) {
+	if v.len == cap(v.new) {
+		v.grow(1)
+	}
+	v.new[v.len] = el
+	v.len++
+	v.carry(NItemsToMoveOnEachInsert)
+}
+
+// Append pushes back every element
+func (v *ManualVec[T]) Append(el ...T) {
+	for i := range el {
+		v.Push(el[i])
+	}
+}
+
+// PopBack pops the last element of the array, returns null if the array is empty
+func (v *ManualVec[T]) PopBack() T {
+	var t T
+	if v.len == 0 {
+		return t
+	}
+	popped := *v.ref(v.len - 1)
+	v.len--
+	if v.moved > v.len {
+		v.moved = v.len
+		if v.moved == 0 {
+			v.freeOld()
+		}
+	}
+	return popped
+}
+
+// Clear empties the array keeping the capacity
+func (v *ManualVec[T]) Clear() {
+	v.freeOld()
+	v.len = 0
+}
+
+// All returns an iterator over the indexes and elements of the ManualVec (from Go 1.23 it can be used with range over func)
+func (v *ManualVec[T]) All() func(yield func(index int, el T) bool) {
+	return func(yield func(index int, el T) bool) {
+		for i := 0; i < v.len; i++ {
+			if !yield(i, *v.ref(i)) {
+				return
+			}
+		}
+	}
+}
+
+// Iter generates an array of elements on the Go heap (allocates space for the iteration)
+func (v *ManualVec[T]) Iter() []T {
+	elements := make([]T, v.len)
+	copy(elements, v.old[:v.moved])
+	copy(elements[v.moved:], v.new[v.moved:v.len])
+	return elements
+}
+
+// carry copies up to n elements from the end of the old region to the new one
+func (v *ManualVec[T]) carry(n int) {
+	if v.old == nil {
+		return
+	}
+	n = min(n, v.moved)
+	copy(v.new[v.moved-n:v.moved], v.old[v.moved-n:v.moved])
+	v.moved -= n
+	if v.moved == 0 {
+		v.freeOld()
+	}
+}
+
+func (v *ManualVec[T]) grow(growFactor int) {
+	// the old region must be empty before it's replaced
+	v.carry(v.moved)
+	// doubling fits the len/NItemsToMoveOnEachInsert pushes we need to move every element
+	capacity := max(max(cap(v.new)*2, v.len+growFactor), minManualCapacity)
+	elements, mem := manualAlloc[T](capacity)
+	if v.len > 0 {
+		v.old, v.oldMem = v.new, v.newMem
+		v.moved = v.len
+	} else if v.newMem != nil {
+		freeManualMemory(v.newMem)
+	}
+	v.new, v.newMem = elements, mem
+}
+
+// minManualCapacity is the capacity of the first region of a ManualVec
+const minManualCapacity = 8
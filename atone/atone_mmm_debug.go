@@ -0,0 +1,20 @@
+//go:build atonedebug
+
+package atone
+
+import (
+	"log"
+	"runtime"
+	"runtime/debug"
+)
+
+// trackManualVec reports the ManualVecs that are collected without having been freed, with the stack where
+// they were created
+func trackManualVec[T any](v *ManualVec[T]) {
+	stack := debug.Stack()
+	runtime.SetFinalizer(v, func(v *ManualVec[T]) {
+		if v.allocated() {
+			log.Printf("atone: ManualVec with %d elements was never freed, it was created at:\n%s", v.len, stack)
+		}
+	})
+}
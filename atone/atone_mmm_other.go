@@ -0,0 +1,14 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package atone
+
+import "unsafe"
+
+// allocManualMemory falls back to the Go heap where there is no mmap, the memory is still pointer free so the GC
+// doesn't scan it. It's allocated as uint64s so it's aligned for any T.
+func allocManualMemory(size int) []byte {
+	words := make([]uint64, (size+7)/8)
+	return unsafe.Slice((*byte)(unsafe.Pointer(&words[0])), size)
+}
+
+func freeManualMemory(mem []byte) {}
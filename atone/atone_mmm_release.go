@@ -0,0 +1,6 @@
+//go:build !atonedebug
+
+package atone
+
+// trackManualVec only does something when building with the atonedebug tag, see atone_mmm_debug.go
+func trackManualVec[T any](v *ManualVec[T]) {}
@@ -0,0 +1,20 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package atone
+
+import "syscall"
+
+// allocManualMemory maps size bytes of anonymous memory outside of the Go heap
+func allocManualMemory(size int) []byte {
+	mem, err := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		panic("atone: mmap failed: " + err.Error())
+	}
+	return mem
+}
+
+func freeManualMemory(mem []byte) {
+	if err := syscall.Munmap(mem); err != nil {
+		panic("atone: munmap failed: " + err.Error())
+	}
+}
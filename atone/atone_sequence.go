@@ -0,0 +1,180 @@
+package atone
+
+import "reflect"
+
+// Sequence is the interface of the containers of this package that keep their elements in order, indexed from
+// 0 to Len()-1. It lets code work with a Vec, a plain slice (through SliceSeq) or any other container without
+// depending on the concrete type. FileVec and DurableVec don't implement it because their Push can fail, and
+// SortedVec doesn't because the order of its elements is not up to the caller.
+type Sequence[T any] interface {
+	// Get returns the element in the specified index, can panic if it is outofbounds
+	Get(index int) T
+	// Set sets the element in the specified index, can panic if it is outofbounds
+	Set(index int, el T)
+	// Len returns the number of elements
+	Len() int
+	// Push pushes back an element
+	Push(el T)
+	// PopBack pops the last element, returns null if the sequence is empty
+	PopBack() T
+	// All returns an iterator over the indexes and elements in order (from Go 1.23 it can be used with range over func)
+	All() func(yield func(index int, el T) bool)
+}
+
+var (
+	_ Sequence[int] = (*Vec[int])(nil)
+	_ Sequence[int] = (*SyncVec[int])(nil)
+	_ Sequence[int] = (*SliceSeq[int])(nil)
+)
+
+// SliceSeq is a plain slice that implements Sequence, use it as (*SliceSeq[T])(&slice)
+type SliceSeq[T any] []T
+
+// Get returns the element in the specified index, can panic if it is outofbounds
+func (s *SliceSeq[T]) Get(index int) T {
+	return (*s)[index]
+}
+
+// Set sets the element in the specified index, can panic if it is outofbounds
+func (s *SliceSeq[T]) Set(index int, el T) {
+	(*s)[index] = el
+}
+
+// Len returns the number of elements stored in the slice
+func (s *SliceSeq[T]) Len() int {
+	return len(*s)
+}
+
+// Push appends an element to the slice
+func (s *SliceSeq[T]) Push(el T) {
+	*s = append(*s, el)
+}
+
+// PopBack pops the last element of the slice, returns null if the slice is empty
+func (s *SliceSeq[T]) PopBack() T {
+	var t T
+	if len(*s) == 0 {
+		return t
+	}
+	popped := (*s)[len(*s)-1]
+	(*s)[len(*s)-1] = t
+	*s = (*s)[:len(*s)-1]
+	return popped
+}
+
+// All returns an iterator over the indexes and elements of the slice
+func (s *SliceSeq[T]) All() func(yield func(index int, el T) bool) {
+	return func(yield func(index int, el T) bool) {
+		for i, el := range *s {
+			if !yield(i, el) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the indexes and elements of the Vec (from Go 1.23 it can be used with range over func),
+// the Vec must not be changed meanwhile
+func (v *Vec[T]) All() func(yield func(index int, el T) bool) {
+	return func(yield func(index int, el T) bool) {
+		for i := range v.oldHead {
+			if !yield(i, v.oldHead[i]) {
+				return
+			}
+		}
+		oldLen := v.oldLen()
+		for i := range v.newTail {
+			if !yield(oldLen+i, v.newTail[i]) {
+				return
+			}
+		}
+	}
+}
+
+// Collect returns a new slice with the elements of the sequence
+func Collect[T any](s Sequence[T]) []T {
+	elements := make([]T, 0, s.Len())
+	s.All()(func(_ int, el T) bool {
+		elements = append(elements, el)
+		return true
+	})
+	return elements
+}
+
+// sameSequence returns true if a and b are the same pointer, it's false for sequences that are not pointers
+func sameSequence[T any](a, b Sequence[T]) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	return va.Kind() == reflect.Ptr && va.Type() == vb.Type() && va.Pointer() == vb.Pointer()
+}
+
+// holdsLock returns true if iterating over s holds a lock (it's a SyncVec), so calling another sequence that
+// locks meanwhile could deadlock with a call that has both sequences the other way around
+func holdsLock[T any](s Sequence[T]) bool {
+	_, ok := s.(*SyncVec[T])
+	return ok
+}
+
+// Extend pushes every element of src into dst, if both are the same sequence its elements are pushed once
+// again
+func Extend[T any](dst Sequence[T], src Sequence[T]) {
+	if sameSequence(dst, src) || holdsLock(src) {
+		// pushing while iterating over the same sequence would never end (or deadlock for a SyncVec), and
+		// src is copied under its own lock first so dst isn't locked while holding it
+		for _, el := range Collect(src) {
+			dst.Push(el)
+		}
+		return
+	}
+	src.All()(func(_ int, el T) bool {
+		dst.Push(el)
+		return true
+	})
+}
+
+// IndexFunc returns the index of the first element that satisfies fn, or -1
+func IndexFunc[T any](s Sequence[T], fn func(el T) bool) int {
+	index := -1
+	s.All()(func(i int, el T) bool {
+		if fn(el) {
+			index = i
+			return false
+		}
+		return true
+	})
+	return index
+}
+
+// ContainsFunc returns true if any element satisfies fn
+func ContainsFunc[T any](s Sequence[T], fn func(el T) bool) bool {
+	return IndexFunc(s, fn) >= 0
+}
+
+// Equal returns true if both sequences have the same elements in the same order
+func Equal[T comparable](a, b Sequence[T]) bool {
+	if sameSequence(a, b) {
+		return true
+	}
+	if holdsLock(a) {
+		// compare a copy of a, so b isn't locked while holding the lock of a
+		elements := Collect(a)
+		a = (*SliceSeq[T])(&elements)
+	}
+	if a.Len() != b.Len() {
+		return false
+	}
+	equal := true
+	a.All()(func(i int, el T) bool {
+		equal = b.Get(i) == el
+		return equal
+	})
+	return equal
+}
+
+// Reverse reverses the sequence in place
+func Reverse[T any](s Sequence[T]) {
+	for i, j := 0, s.Len()-1; i < j; i, j = i+1, j-1 {
+		a, b := s.Get(i), s.Get(j)
+		s.Set(i, b)
+		s.Set(j, a)
+	}
+}
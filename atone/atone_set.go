@@ -0,0 +1,120 @@
+package atone
+
+// Set is a set backed by an atone Map, so it grows incrementally too. Like Map, the zero value is an empty set
+// ready to use.
+type Set[T comparable] struct {
+	m Map[T, struct{}]
+}
+
+// NewSet returns a new Set with the passed elements
+func NewSet[T comparable](elements ...T) *Set[T] {
+	s := NewSetWithCapacity[T](len(elements))
+	for _, el := range elements {
+		s.Add(el)
+	}
+	return s
+}
+
+// NewSetWithCapacity returns a new Set that fits capacity elements without growing
+func NewSetWithCapacity[T comparable](capacity int) *Set[T] {
+	return &Set[T]{m: *NewMapWithCapacity[T, struct{}](capacity)}
+}
+
+// Add adds el to the set, returns false if it was already there
+func (s *Set[T]) Add(el T) bool {
+	if s.m.Has(el) {
+		return false
+	}
+	s.m.Set(el, struct{}{})
+	return true
+}
+
+// Remove removes el from the set, returns false if it wasn't there
+func (s *Set[T]) Remove(el T) bool {
+	return s.m.Delete(el)
+}
+
+// Has returns true if el is in the set
+func (s *Set[T]) Has(el T) bool {
+	return s.m.Has(el)
+}
+
+// Len returns the number of elements in the set
+func (s *Set[T]) Len() int {
+	return s.m.Len()
+}
+
+// IsEmpty returns if there is any element in the set or not
+func (s *Set[T]) IsEmpty() bool {
+	return s.m.IsEmpty()
+}
+
+// Clear empties the set
+func (s *Set[T]) Clear() {
+	s.m.Clear()
+}
+
+// ForEach iterates through the set doing a callback to the passed function, the set must not be changed meanwhile
+func (s *Set[T]) ForEach(fn func(el T)) {
+	s.m.ForEach(func(el T, _ struct{}) { fn(el) })
+}
+
+// All returns an iterator over the elements of the set (from Go 1.23 it can be used with range over func),
+// the set must not be changed meanwhile
+func (s *Set[T]) All() func(yield func(el T) bool) {
+	return func(yield func(el T) bool) {
+		s.m.All()(func(el T, _ struct{}) bool { return yield(el) })
+	}
+}
+
+// Iter generates an array of elements (allocates space for the iteration)
+func (s *Set[T]) Iter() []T {
+	elements := make([]T, 0, s.Len())
+	s.ForEach(func(el T) { elements = append(elements, el) })
+	return elements
+}
+
+// Union returns a new set with the elements that are in s or in other
+func (s *Set[T]) Union(other *Set[T]) *Set[T] {
+	union := NewSetWithCapacity[T](max(s.Len(), other.Len()))
+	s.ForEach(func(el T) { union.Add(el) })
+	other.ForEach(func(el T) { union.Add(el) })
+	return union
+}
+
+// Intersect returns a new set with the elements that are both in s and in other
+func (s *Set[T]) Intersect(other *Set[T]) *Set[T] {
+	smaller, bigger := s, other
+	if smaller.Len() > bigger.Len() {
+		smaller, bigger = bigger, smaller
+	}
+	intersection := NewSet[T]()
+	smaller.ForEach(func(el T) {
+		if bigger.Has(el) {
+			intersection.Add(el)
+		}
+	})
+	return intersection
+}
+
+// Difference returns a new set with the elements of s that are not in other
+func (s *Set[T]) Difference(other *Set[T]) *Set[T] {
+	difference := NewSet[T]()
+	s.ForEach(func(el T) {
+		if !other.Has(el) {
+			difference.Add(el)
+		}
+	})
+	return difference
+}
+
+// SymmetricDifference returns a new set with the elements that are only in one of s and other
+func (s *Set[T]) SymmetricDifference(other *Set[T]) *Set[T] {
+	difference := s.Difference(other)
+	other.ForEach(func(el T) {
+		if !s.Has(el) {
+			difference.Add(el)
+		}
+	})
+	return difference
+}
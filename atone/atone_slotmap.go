@@ -0,0 +1,114 @@
+package atone
+
+// Handle identifies an element of a SlotMap. The generation tells apart the elements that used the same slot,
+// so a Handle of a removed element never finds the element that reused its slot. The zero Handle is never valid.
+type Handle struct {
+	Index      uint32
+	Generation uint32
+}
+
+// SlotMap stores elements in slots and returns Handles to them that stay valid until they are removed. Removed
+// slots are reused, and looking up a Handle is an index plus a generation check.
+type SlotMap[T any] struct {
+	slots *Vec[slot[T]]
+	free  *Vec[uint32]
+	len   int
+}
+
+type slot[T any] struct {
+	value T
+	// generation is odd while the slot is used and even while it's free
+	generation uint32
+}
+
+// NewSlotMap returns a new SlotMap
+func NewSlotMap[T any]() *SlotMap[T] {
+	return &SlotMap[T]{slots: New[slot[T]](), free: New[uint32]()}
+}
+
+// Insert stores el and returns its Handle
+func (s *SlotMap[T]) Insert(el T) Handle {
+	s.len++
+	if s.free.IsEmpty() {
+		index := uint32(s.slots.Len())
+		s.slots.Push(slot[T]{value: el, generation: 1})
+		return Handle{Index: index, Generation: 1}
+	}
+	index := s.free.PopBack()
+	sl := s.slots.GetRef(int(index))
+	sl.value = el
+	sl.generation++
+	return Handle{Index: index, Generation: sl.generation}
+}
+
+// lookup returns the slot of the handle, nil if the handle is stale or invalid
+func (s *SlotMap[T]) lookup(h Handle) *slot[T] {
+	if int(h.Index) >= s.slots.Len() || h.Generation%2 == 0 {
+		return nil
+	}
+	sl := s.slots.GetRef(int(h.Index))
+	if sl.generation != h.Generation {
+		return nil
+	}
+	return sl
+}
+
+// Get returns the element of the handle, the boolean is false if it was removed
+func (s *SlotMap[T]) Get(h Handle) (T, bool) {
+	var defaul T
+	sl := s.lookup(h)
+	if sl == nil {
+		return defaul, false
+	}
+	return sl.value, true
+}
+
+// GetRef returns a pointer to the element of the handle, nil if it was removed. The pointer is valid until
+// the next Insert.
+func (s *SlotMap[T]) GetRef(h Handle) *T {
+	sl := s.lookup(h)
+	if sl == nil {
+		return nil
+	}
+	return &sl.value
+}
+
+// Contains returns true if the element of the handle hasn't been removed
+func (s *SlotMap[T]) Contains(h Handle) bool {
+	return s.lookup(h) != nil
+}
+
+// Remove removes the element of the handle and returns it, the boolean is false if it was already removed
+func (s *SlotMap[T]) Remove(h Handle) (T, bool) {
+	var defaul T
+	sl := s.lookup(h)
+	if sl == nil {
+		return defaul, false
+	}
+	el := sl.value
+	// release the element so it can be collected
+	sl.value = defaul
+	sl.generation++
+	s.free.Push(h.Index)
+	s.len--
+	return el, true
+}
+
+// Len returns the number of elements stored
+func (s *SlotMap[T]) Len() int {
+	return s.len
+}
+
+// IsEmpty returns if there is any element or not
+func (s *SlotMap[T]) IsEmpty() bool {
+	return s.len == 0
+}
+
+// ForEach iterates through the elements doing a callback to the passed function with their handles
+func (s *SlotMap[T]) ForEach(fn func(h Handle, el T)) {
+	s.slots.ForEach(func(sl slot[T], index int) {
+		if sl.generation%2 == 1 {
+			fn(Handle{Index: uint32(index), Generation: sl.generation}, sl.value)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package atone
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// View is an immutable point in time view of a Vec, see Vec.Snapshot. It shares the storage with the
+// Vec it comes from, the Vec copies a segment before changing any element that a View can still read.
+type View[T any] struct {
+	vec Vec[T]
+}
+
+// Snapshot returns an immutable view of the Vec as it was after the last change. The view shares the storage
+// with the Vec: the Vec keeps pushing without copying anything, and only copies the oldHead or the newTail
+// when a later change (Swap, Truncate, a Push after a PopBack...) would overwrite elements a view can see.
+//
+// The first call starts publishing a view on every change and must be done by the goroutine that owns the
+// Vec, or before the Vec is shared. After that Snapshot is an atomic load that any goroutine can do
+// while the owner keeps changing the Vec.
+func (v *Vec[T]) Snapshot() *View[T] {
+	if view := atomic.LoadPointer(&v.view); view != nil {
+		return (*View[T])(view)
+	}
+	v.snapshots = true
+	v.publish()
+	return (*View[T])(atomic.LoadPointer(&v.view))
+}
+
+// publish makes the current state of the Vec visible for Snapshot, marking everything it contains as shared
+func (v *Vec[T]) publish() {
+	if !v.snapshots {
+		return
+	}
+	lenOld, lenNew := v.oldLen(), len(v.newTail)
+	v.oldShared = max(v.oldShared, lenOld)
+	if v.newTailFollowsOldHead() {
+		v.oldShared = max(v.oldShared, lenOld+lenNew)
+	}
+	v.newShared = max(v.newShared, lenNew)
+	view := &View[T]{vec: Vec[T]{
+		oldHead: v.oldHead[:lenOld:lenOld],
+		newTail: v.newTail[:lenNew:lenNew],
+	}}
+	atomic.StorePointer(&v.view, unsafe.Pointer(view))
+}
+
+// oldIsShared returns true if a view can read the element in index of the oldHead
+func (v *Vec[T]) oldIsShared(index int) bool {
+	return v.snapshots && index < v.oldShared
+}
+
+// newIsShared returns true if a view can read the element in index of the newTail
+func (v *Vec[T]) newIsShared(index int) bool {
+	return v.snapshots && (index < v.newShared || v.newTailFollowsOldHead() && v.oldLen()+index < v.oldShared)
+}
+
+// ownOld copies the oldHead if the element in index can be read by a view
+func (v *Vec[T]) ownOld(index int) {
+	if !v.oldIsShared(index) {
+		return
+	}
+	elements := make([]T, v.oldLen(), cap(v.oldHead))
+	copy(elements, v.oldHead)
+	v.oldHead = elements
+	v.oldShared = 0
+}
+
+// ownNew copies the newTail if the element in index can be read by a view
+func (v *Vec[T]) ownNew(index int) {
+	if !v.newIsShared(index) {
+		return
+	}
+	elements := make([]T, len(v.newTail), cap(v.newTail))
+	copy(elements, v.newTail)
+	v.newTail = elements
+	v.newShared = 0
+}
+
+// ownIndex copies the segment that contains index if a view can read it
+func (v *Vec[T]) ownIndex(index int) {
+	if index < v.oldLen() {
+		v.ownOld(index)
+		return
+	}
+	v.ownNew(index - v.oldLen())
+}
+
+// detach moves every element to new storage that no view can read, leaving the oldHead empty
+func (v *Vec[T]) detach() {
+	if !v.snapshots || v.oldShared == 0 && v.newShared == 0 {
+		return
+	}
+	lenOld := v.oldLen()
+	elements := make([]T, lenOld+len(v.newTail), max(cap(v.newTail), lenOld+len(v.newTail)))
+	copy(elements, v.oldHead)
+	copy(elements[lenOld:], v.newTail)
+	v.oldHead = nil
+	v.newTail = elements
+	v.oldShared = 0
+	v.newShared = 0
+}
+
+// Len returns the number of elements in the view
+func (w *View[T]) Len() int {
+	return w.vec.Len()
+}
+
+// IsEmpty returns if there is any element in the view or not
+func (w *View[T]) IsEmpty() bool {
+	return w.vec.IsEmpty()
+}
+
+// Lookup returns an element, the boolean is false if the element does not exist.
+func (w *View[T]) Lookup(index int) (T, bool) {
+	return w.vec.Lookup(index)
+}
+
+// Get returns the element in the specified index, can panic if it is outofbounds, if you don't want to panic on get, use Lookup
+func (w *View[T]) Get(index int) T {
+	return w.vec.Get(index)
+}
+
+// First returns the first element of the view, returns null if it is empty
+func (w *View[T]) First() T {
+	return w.vec.First()
+}
+
+// Last returns the last element of the view, returns null if it is empty
+func (w *View[T]) Last() T {
+	return w.vec.Last()
+}
+
+// Find finds doing a lookup in head and then in tail
+func (w *View[T]) Find(el T, cb func(element T) bool) int {
+	return w.vec.Find(el, cb)
+}
+
+// Contains returns true if the element is inside the view
+func (w *View[T]) Contains(el T, cb func(T) bool) bool {
+	return w.vec.Contains(el, cb)
+}
+
+// ForEach iterates through the view doing a callback to the passed function
+func (w *View[T]) ForEach(fn func(el T, index int)) {
+	w.vec.ForEach(fn)
+}
+
+// Iter generates an array of elements (allocates space for the iteration)
+func (w *View[T]) Iter() []T {
+	return w.vec.Iter()
+}
+
+// Slice generates a slice slicing the view from start to end (end is not inclusive and start is)
+func (w *View[T]) Slice(start, end int) []T {
+	return w.vec.Slice(start, end)
+}
@@ -0,0 +1,268 @@
+package atone
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+	"sort"
+	"unsafe"
+)
+
+// maxSortFanIn is the number of runs that are merged at once, if there are more of them they are merged in
+// several passes so the number of open files and read buffers stays bounded
+const maxSortFanIn = 64
+
+// ExternalSortFunc sorts v by cmp (which returns a negative number if a goes before b, a positive one if it
+// goes after and 0 if they are equal). It sorts runs of elements that fit in memBudget bytes, writes them with
+// codec to temp files in tmpDir (os.TempDir() if it's "") and merges them into a single sorted file. Only then
+// v gives back its arrays and the sorted elements are read into it, so besides the elements of v it only keeps
+// memBudget bytes of them in memory. The sort is stable and the temp files are removed before returning. If it
+// fails v keeps its elements as they were, unless reading the sorted file fails, then v only has the elements
+// read until that point.
+func ExternalSortFunc[T any](v *Vec[T], cmp func(a, b T) int, memBudget int, tmpDir string, codec Codec[T]) error {
+	s := newExternalSorter(cmp, memBudget, tmpDir, codec)
+	defer s.close()
+	if err := s.split(v); err != nil {
+		return err
+	}
+	if s.runs == nil {
+		v.Clear()
+		v.Append(s.run...)
+		return nil
+	}
+	if err := s.reduce(1); err != nil {
+		return err
+	}
+	n := v.Len()
+	v.Free()
+	// a single array with room for every element, growing would keep two of them at once
+	v.newTail = v.allocate(n)
+	return s.mergeRuns(s.runs, func(el T) error {
+		v.Push(el)
+		return nil
+	})
+}
+
+// ExternalSortFuncTo is like ExternalSortFunc but instead of changing v it passes the sorted elements one
+// by one to out, stopping at the first error it returns
+func ExternalSortFuncTo[T any](v *Vec[T], cmp func(a, b T) int, memBudget int, tmpDir string, codec Codec[T], out func(el T) error) error {
+	s := newExternalSorter(cmp, memBudget, tmpDir, codec)
+	defer s.close()
+	if err := s.split(v); err != nil {
+		return err
+	}
+	if s.runs == nil {
+		for _, el := range s.run {
+			if err := out(el); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return s.merge(out)
+}
+
+type externalSorter[T any] struct {
+	cmp     func(a, b T) int
+	codec   Codec[T]
+	dir     string
+	runSize int
+	// run is the run being sorted in memory
+	run []T
+	// runs are the sorted runs written so far, in order, nil if everything fit in memory
+	runs  []*os.File
+	files []*os.File
+	buf   []byte
+}
+
+func newExternalSorter[T any](cmp func(a, b T) int, memBudget int, tmpDir string, codec Codec[T]) *externalSorter[T] {
+	var zero T
+	return &externalSorter[T]{
+		cmp:     cmp,
+		codec:   codec,
+		dir:     tmpDir,
+		runSize: max(memBudget/max(int(unsafe.Sizeof(zero)), 1), 1),
+	}
+}
+
+// split sorts runs of v, if v fits in a single run it stays in s.run instead of being written
+func (s *externalSorter[T]) split(v *Vec[T]) error {
+	n := v.Len()
+	s.run = make([]T, 0, min(s.runSize, n))
+	for start := 0; start < n; start += s.runSize {
+		s.run = s.run[:0]
+		for i := start; i < n && i < start+s.runSize; i++ {
+			s.run = append(s.run, v.Get(i))
+		}
+		sort.SliceStable(s.run, func(i, j int) bool {
+			return s.cmp(s.run[i], s.run[j]) < 0
+		})
+		if start == 0 && len(s.run) == n {
+			return nil
+		}
+		if err := s.writeRun(s.run); err != nil {
+			return err
+		}
+	}
+	s.run = nil
+	return nil
+}
+
+// create creates a temp file that close removes
+func (s *externalSorter[T]) create() (*os.File, error) {
+	file, err := os.CreateTemp(s.dir, "atone-sort-*")
+	if err != nil {
+		return nil, err
+	}
+	s.files = append(s.files, file)
+	return file, nil
+}
+
+// write writes an element to a run as [u32 len][encoding]
+func (s *externalSorter[T]) write(w *bufio.Writer, el T) error {
+	b := append(s.buf[:0], 0, 0, 0, 0)
+	b, err := s.codec.Encode(b, el)
+	if err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(b, uint32(len(b)-4))
+	s.buf = b
+	_, err = w.Write(b)
+	return err
+}
+
+func (s *externalSorter[T]) writeRun(run []T) error {
+	file, err := s.create()
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	for i := range run {
+		if err := s.write(w, run[i]); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	s.runs = append(s.runs, file)
+	return nil
+}
+
+// sortRun reads back a run written by writeRun
+type sortRun[T any] struct {
+	r *bufio.Reader
+	// index is the position of the run, equal elements of earlier runs go first
+	index int
+	el    T
+	buf   []byte
+}
+
+// next reads the next element of the run into el, it returns io.EOF at the end of the run
+func (r *sortRun[T]) next(codec Codec[T]) error {
+	var header [4]byte
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		return err
+	}
+	length := int(binary.LittleEndian.Uint32(header[:]))
+	if cap(r.buf) < length {
+		r.buf = make([]byte, length)
+	}
+	r.buf = r.buf[:length]
+	if _, err := io.ReadFull(r.r, r.buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	el, err := codec.Decode(r.buf)
+	r.el = el
+	return err
+}
+
+// merge merges the runs passing the elements to out, first merging groups of runs until there are at most
+// maxSortFanIn of them
+func (s *externalSorter[T]) merge(out func(el T) error) error {
+	if err := s.reduce(maxSortFanIn); err != nil {
+		return err
+	}
+	return s.mergeRuns(s.runs, out)
+}
+
+// reduce merges groups of up to maxSortFanIn runs into new runs until there are at most n of them
+func (s *externalSorter[T]) reduce(n int) error {
+	for len(s.runs) > n {
+		var merged []*os.File
+		for start := 0; start < len(s.runs); start += maxSortFanIn {
+			file, err := s.create()
+			if err != nil {
+				return err
+			}
+			w := bufio.NewWriter(file)
+			err = s.mergeRuns(s.runs[start:min(start+maxSortFanIn, len(s.runs))], func(el T) error {
+				return s.write(w, el)
+			})
+			if err != nil {
+				return err
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			merged = append(merged, file)
+		}
+		s.runs = merged
+	}
+	return nil
+}
+
+// mergeRuns does a k-way merge of the runs with a Heap, and truncates each run file once it's merged
+func (s *externalSorter[T]) mergeRuns(runs []*os.File, out func(el T) error) error {
+	heap := NewHeap(func(a, b *sortRun[T]) bool {
+		c := s.cmp(a.el, b.el)
+		return c < 0 || c == 0 && a.index < b.index
+	})
+	for i, file := range runs {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		run := &sortRun[T]{r: bufio.NewReader(file), index: i}
+		if err := run.next(s.codec); err == nil {
+			heap.Push(run)
+		} else if err != io.EOF {
+			return err
+		}
+	}
+	for !heap.IsEmpty() {
+		run := heap.Peek()
+		if err := out(run.el); err != nil {
+			return err
+		}
+		err := run.next(s.codec)
+		if err == io.EOF {
+			heap.Pop()
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		heap.Fix(0)
+	}
+	// the runs are not needed anymore, give back their space before the next pass
+	for _, file := range runs {
+		if err := file.Truncate(0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// close closes and removes every temp file
+func (s *externalSorter[T]) close() {
+	for _, file := range s.files {
+		file.Close()
+		os.Remove(file.Name())
+	}
+	s.files = nil
+	s.runs = nil
+}
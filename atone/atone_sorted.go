@@ -0,0 +1,129 @@
+package atone
+
+// SortedVec keeps its elements sorted by less, Insert and Remove binary search the position. Equal elements
+// are allowed and kept in insertion order, check Contains before inserting to use it as an ordered set.
+type SortedVec[T any] struct {
+	vec  *Vec[T]
+	less func(a, b T) bool
+}
+
+// NewSortedVec returns a new SortedVec ordered by less
+func NewSortedVec[T any](less func(a, b T) bool) *SortedVec[T] {
+	return &SortedVec[T]{vec: New[T](), less: less}
+}
+
+// search returns the index of the first element that is not less than el (if orEqual is false) or
+// the first one greater than el (if orEqual is true)
+func (s *SortedVec[T]) search(el T, orEqual bool) int {
+	lo, hi := 0, s.vec.Len()
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		current := s.vec.Get(mid)
+		var before bool
+		if orEqual {
+			before = !s.less(el, current)
+		} else {
+			before = s.less(current, el)
+		}
+		if before {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// Len returns the number of elements
+func (s *SortedVec[T]) Len() int {
+	return s.vec.Len()
+}
+
+// IsEmpty returns if there is any element or not
+func (s *SortedVec[T]) IsEmpty() bool {
+	return s.vec.IsEmpty()
+}
+
+// Insert inserts el after the elements that are less or equal than it and returns its index
+func (s *SortedVec[T]) Insert(el T) int {
+	index := s.search(el, true)
+	s.vec.InsertAt(index, el)
+	return index
+}
+
+// Remove removes an element equal to el, returns false if there isn't any
+func (s *SortedVec[T]) Remove(el T) bool {
+	index := s.search(el, false)
+	if index == s.vec.Len() || s.less(el, s.vec.Get(index)) {
+		return false
+	}
+	s.vec.RemoveAt(index)
+	return true
+}
+
+// RemoveAt removes the element in the specified index and returns it, can panic if it is outofbounds
+func (s *SortedVec[T]) RemoveAt(index int) T {
+	return s.vec.RemoveAt(index)
+}
+
+// Contains returns true if there is an element equal to el
+func (s *SortedVec[T]) Contains(el T) bool {
+	index := s.search(el, false)
+	return index < s.vec.Len() && !s.less(el, s.vec.Get(index))
+}
+
+// Rank returns the number of elements that are less than el
+func (s *SortedVec[T]) Rank(el T) int {
+	return s.search(el, false)
+}
+
+// Select returns the k-th smallest element (starting from 0), can panic if it is outofbounds
+func (s *SortedVec[T]) Select(k int) T {
+	return s.vec.Get(k)
+}
+
+// Get is the same as Select
+func (s *SortedVec[T]) Get(index int) T {
+	return s.vec.Get(index)
+}
+
+// First returns the smallest element, returns null if it is empty
+func (s *SortedVec[T]) First() T {
+	return s.vec.First()
+}
+
+// Last returns the greatest element, returns null if it is empty
+func (s *SortedVec[T]) Last() T {
+	return s.vec.Last()
+}
+
+// PopFront pops the smallest element, returns null if it is empty
+func (s *SortedVec[T]) PopFront() T {
+	return s.vec.PopFront()
+}
+
+// PopBack pops the greatest element, returns null if it is empty
+func (s *SortedVec[T]) PopBack() T {
+	return s.vec.PopBack()
+}
+
+// Range calls fn in order with every element that is not less than lo and less than hi, stopping if fn returns false.
+// The SortedVec must not be changed meanwhile.
+func (s *SortedVec[T]) Range(lo, hi T, fn func(el T) bool) {
+	for i, n := s.search(lo, false), s.vec.Len(); i < n; i++ {
+		el := s.vec.Get(i)
+		if !s.less(el, hi) || !fn(el) {
+			return
+		}
+	}
+}
+
+// All returns an iterator over the indexes and elements in order (from Go 1.23 it can be used with range over func)
+func (s *SortedVec[T]) All() func(yield func(index int, el T) bool) {
+	return s.vec.All()
+}
+
+// Iter generates an array of the elements in order (allocates space for the iteration)
+func (s *SortedVec[T]) Iter() []T {
+	return s.vec.Iter()
+}
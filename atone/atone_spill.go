@@ -0,0 +1,375 @@
+package atone
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"sort"
+	"unsafe"
+)
+
+// SpillVec is a Vec that keeps at most memBudget bytes of elements in memory, the rest of them are encoded
+// with a Codec into a temp file. Elements are grouped in pages of spillPageSize elements: the page being
+// pushed to is always in memory, and the rest of pages that are in memory are kept in an LRU, a page that
+// gets evicted is written to the temp file (if it changed) and read back on the next Get that needs it. A page
+// is written again where it was if it still fits, otherwise it's moved to free space of the file.
+//
+// The budget counts unsafe.Sizeof(T) per element, memory referenced by the elements (like the bytes of a
+// string) is not counted. The first I/O or codec error of the temp file is returned by Err: a page that can't
+// be written stays in memory, and a page that can't be read makes Get return null, Set, Push and PopBack do
+// nothing and All stop. Close removes the temp file.
+type SpillVec[T any] struct {
+	codec Codec[T]
+	dir   string
+	file  *os.File
+	// end is the size of the used part of the file
+	end int64
+	// free are the unused extents of the file before end, by offset and without adjacent ones
+	free  []spillExtent
+	pages *Vec[*spillPage[T]]
+	// cache has the pages that are in memory except the last one, by their number
+	cache *LRU[int, *spillPage[T]]
+	len   int
+	buf   []byte
+	err   error
+}
+
+var _ Sequence[int] = (*SpillVec[int])(nil)
+
+type spillPage[T any] struct {
+	// elements is nil while the page is only in the file
+	elements []T
+	// offset and size are the extent of the file for the page, size is 0 if it was never written, and length
+	// is how much of it the page used when it was last written
+	offset int64
+	size   int64
+	length int
+	// dirty is true if the elements changed after the page was last written
+	dirty bool
+}
+
+type spillExtent struct {
+	offset, size int64
+}
+
+// spillPageSize is the number of elements of a page
+const spillPageSize = 1024
+
+// NewSpillVec returns a new SpillVec that keeps about memBudget bytes of elements in memory and spills the
+// rest to a temp file in dir (os.TempDir() if dir is "")
+func NewSpillVec[T any](memBudget int, codec Codec[T], dir string) *SpillVec[T] {
+	var zero T
+	pageBytes := max(int(unsafe.Sizeof(zero))*spillPageSize, 1)
+	// the last page is always in memory, besides the cached ones
+	cached := max(memBudget/pageBytes-1, 1)
+	s := &SpillVec[T]{codec: codec, dir: dir, pages: New[*spillPage[T]]()}
+	s.cache = NewLRU(cached, func(_ int, page *spillPage[T]) {
+		s.spill(page)
+	})
+	return s
+}
+
+// Err returns the first error reading or writing the temp file, nil if there was none
+func (s *SpillVec[T]) Err() error {
+	return s.err
+}
+
+func (s *SpillVec[T]) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// spill writes the page to the file if it's not there yet, and drops its elements from memory
+func (s *SpillVec[T]) spill(page *spillPage[T]) {
+	if (page.dirty || page.size == 0) && !s.write(page) {
+		// the elements stay in memory, the page is loaded from there the next time
+		return
+	}
+	page.elements = nil
+}
+
+// write writes the page to the file, where it was if it fits
+func (s *SpillVec[T]) write(page *spillPage[T]) bool {
+	if s.file == nil {
+		file, err := os.CreateTemp(s.dir, "atone-spill-*")
+		if err != nil {
+			s.fail(err)
+			return false
+		}
+		s.file = file
+	}
+	b := append(s.buf[:0], 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(b, uint32(len(page.elements)))
+	for i := range page.elements {
+		start := len(b)
+		b = append(b, 0, 0, 0, 0)
+		var err error
+		if b, err = s.codec.Encode(b, page.elements[i]); err != nil {
+			s.fail(err)
+			return false
+		}
+		binary.LittleEndian.PutUint32(b[start:], uint32(len(b)-start-4))
+	}
+	s.buf = b
+	offset, size := page.offset, page.size
+	if int64(len(b)) > size {
+		// a page that outgrew its extent is likely to grow again, leave it some room
+		size = int64(len(b))
+		if page.size > 0 {
+			size += size / 4
+		}
+		offset = s.alloc(size)
+	}
+	if _, err := s.file.WriteAt(b, offset); err != nil {
+		if size != page.size {
+			s.release(offset, size)
+		}
+		s.fail(err)
+		return false
+	}
+	if size != page.size {
+		s.release(page.offset, page.size)
+		page.offset, page.size = offset, size
+	}
+	page.length = len(b)
+	page.dirty = false
+	return true
+}
+
+// alloc returns the offset of size free bytes of the file, from the first free extent they fit in or the end
+func (s *SpillVec[T]) alloc(size int64) int64 {
+	for i, extent := range s.free {
+		if extent.size > size {
+			s.free[i] = spillExtent{offset: extent.offset + size, size: extent.size - size}
+			return extent.offset
+		}
+		if extent.size == size {
+			s.free = append(s.free[:i], s.free[i+1:]...)
+			return extent.offset
+		}
+	}
+	offset := s.end
+	s.end += size
+	return offset
+}
+
+// release frees an extent returned by alloc, merging it with the free extents next to it
+func (s *SpillVec[T]) release(offset, size int64) {
+	if size == 0 {
+		return
+	}
+	i := sort.Search(len(s.free), func(i int) bool {
+		return s.free[i].offset > offset
+	})
+	if i > 0 && s.free[i-1].offset+s.free[i-1].size == offset {
+		i--
+		offset, size = s.free[i].offset, s.free[i].size+size
+		s.free = append(s.free[:i], s.free[i+1:]...)
+	}
+	if i < len(s.free) && offset+size == s.free[i].offset {
+		size += s.free[i].size
+		s.free = append(s.free[:i], s.free[i+1:]...)
+	}
+	if offset+size == s.end {
+		s.end = offset
+		return
+	}
+	s.free = append(s.free, spillExtent{})
+	copy(s.free[i+1:], s.free[i:])
+	s.free[i] = spillExtent{offset: offset, size: size}
+}
+
+// load reads the elements of a page from the file, it returns false if they can't be read
+func (s *SpillVec[T]) load(page *spillPage[T]) bool {
+	// every page is decoded from its own buffer, as the codec might keep the bytes it decodes
+	b := make([]byte, page.length)
+	_, err := s.file.ReadAt(b, page.offset)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		s.fail(err)
+		return false
+	}
+	n := binary.LittleEndian.Uint32(b)
+	b = b[4:]
+	elements := make([]T, n, spillPageSize)
+	for i := range elements {
+		length := binary.LittleEndian.Uint32(b)
+		if elements[i], err = s.codec.Decode(b[4 : 4+length : 4+length]); err != nil {
+			s.fail(err)
+			return false
+		}
+		b = b[4+length:]
+	}
+	page.elements = elements
+	return true
+}
+
+// page returns the page number p with its elements in memory, false if they can't be read
+func (s *SpillVec[T]) page(p int) (*spillPage[T], bool) {
+	if p == s.pages.Len()-1 {
+		return s.last()
+	}
+	if page, ok := s.cache.Get(p); ok {
+		return page, true
+	}
+	page := s.pages.Get(p)
+	if page.elements == nil && !s.load(page) {
+		return nil, false
+	}
+	s.cache.Put(p, page)
+	return page, true
+}
+
+// last returns the last page with its elements in memory, false if they can't be read
+func (s *SpillVec[T]) last() (*spillPage[T], bool) {
+	page := s.pages.Get(s.pages.Len() - 1)
+	if page.elements == nil && !s.load(page) {
+		return nil, false
+	}
+	return page, true
+}
+
+// Len returns the number of elements stored in the array
+func (s *SpillVec[T]) Len() int {
+	return s.len
+}
+
+// IsEmpty returns if there is any element in the array or not
+func (s *SpillVec[T]) IsEmpty() bool {
+	return s.len == 0
+}
+
+// Push pushes back an element into the array
+func (s *SpillVec[T]) Push(el T) {
+	last := s.pages.Len() - 1
+	var page *spillPage[T]
+	if last >= 0 {
+		var ok bool
+		if page, ok = s.last(); !ok {
+			return
+		}
+	}
+	if page == nil || len(page.elements) == spillPageSize {
+		if page != nil {
+			// the full page becomes one more page of the cache, which might spill the coldest one
+			s.cache.Put(last, page)
+		}
+		page = &spillPage[T]{elements: make([]T, 0, spillPageSize)}
+		s.pages.Push(page)
+	}
+	page.elements = append(page.elements, el)
+	page.dirty = true
+	s.len++
+}
+
+// Get returns the element in the specified index, can panic if it is outofbounds, if you don't want to panic on get, use Lookup
+func (s *SpillVec[T]) Get(index int) T {
+	if index < 0 || index >= s.len {
+		panic("atone: SpillVec index out of range")
+	}
+	page, ok := s.page(index / spillPageSize)
+	if !ok {
+		var t T
+		return t
+	}
+	return page.elements[index%spillPageSize]
+}
+
+// Lookup returns an element, the boolean is false if the element does not exist.
+func (s *SpillVec[T]) Lookup(index int) (T, bool) {
+	var defaul T
+	if index < 0 || index >= s.len {
+		return defaul, false
+	}
+	return s.Get(index), true
+}
+
+// Set sets the element in the specified index, can panic if it is outofbounds
+func (s *SpillVec[T]) Set(index int, el T) {
+	if index < 0 || index >= s.len {
+		panic("atone: SpillVec index out of range")
+	}
+	if page, ok := s.page(index / spillPageSize); ok {
+		page.elements[index%spillPageSize] = el
+		page.dirty = true
+	}
+}
+
+// PopBack pops the last element of the array, returns null if the array is empty
+func (s *SpillVec[T]) PopBack() T {
+	var t T
+	if s.len == 0 {
+		return t
+	}
+	page, ok := s.last()
+	if !ok {
+		return t
+	}
+	popped := page.elements[len(page.elements)-1]
+	page.elements[len(page.elements)-1] = t
+	page.elements = page.elements[:len(page.elements)-1]
+	page.dirty = true
+	s.len--
+	if len(page.elements) == 0 {
+		s.pages.PopBack()
+		s.release(page.offset, page.size)
+		// the previous page is the last one now, it's read back on the next change if it's not in memory
+		s.cache.Remove(s.pages.Len() - 1)
+	}
+	return popped
+}
+
+// All returns an iterator over the indexes and elements (from Go 1.23 it can be used with range over func),
+// the SpillVec must not be changed meanwhile
+func (s *SpillVec[T]) All() func(yield func(index int, el T) bool) {
+	return func(yield func(index int, el T) bool) {
+		for p := 0; p < s.pages.Len(); p++ {
+			page, ok := s.page(p)
+			if !ok {
+				return
+			}
+			elements := page.elements
+			for i := range elements {
+				if !yield(p*spillPageSize+i, elements[i]) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// ForEach iterates through the array doing a callback to the passed function
+func (s *SpillVec[T]) ForEach(fn func(el T, index int)) {
+	s.All()(func(index int, el T) bool {
+		fn(el, index)
+		return true
+	})
+}
+
+// Close empties the SpillVec and removes its temp file, it returns the error of Err if there was one
+func (s *SpillVec[T]) Close() error {
+	s.pages = New[*spillPage[T]]()
+	s.cache = NewLRU(s.cache.Cap(), func(_ int, page *spillPage[T]) {
+		s.spill(page)
+	})
+	s.len = 0
+	s.end = 0
+	s.free = nil
+	err := s.err
+	s.err = nil
+	if s.file == nil {
+		return err
+	}
+	name := s.file.Name()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	s.file = nil
+	if removeErr := os.Remove(name); err == nil {
+		err = removeErr
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+package atone
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// SPSCQueue is a lock-free FIFO queue for exactly one producer goroutine and one consumer goroutine.
+//
+// The ring buffer grows the atone way: when it is full the producer doesn't copy anything, it allocates
+// a bigger ring and keeps pushing there while the old ring is kept around until the consumer drains it.
+// The old ring is what the oldHead is for Vec, except that here the items are "carried" by the consumer
+// popping them instead of by the producer, so no push ever pays for moving the whole buffer.
+type SPSCQueue[T any] struct {
+	// producer is only touched by the producer goroutine
+	producer *spscRing[T]
+	_        [cacheLinePad]byte
+	// consumer is only touched by the consumer goroutine
+	consumer *spscRing[T]
+}
+
+// cacheLinePad keeps the fields written by the producer and the ones written by the consumer on
+// different cache lines
+const cacheLinePad = 64
+
+type spscRing[T any] struct {
+	// head is the next index to pop, only written by the consumer
+	head uint64
+	_    [cacheLinePad - 8]byte
+	// tail is the next index to push, only written by the producer
+	tail uint64
+	_    [cacheLinePad - 8]byte
+	// next is the bigger ring (*spscRing[T]) the producer moved to after this one got full
+	next     unsafe.Pointer
+	elements []T
+	mask     uint64
+}
+
+func newSPSCRing[T any](capacity uint64) *spscRing[T] {
+	return &spscRing[T]{
+		elements: make([]T, capacity),
+		mask:     capacity - 1,
+	}
+}
+
+// NewSPSCQueue returns a new SPSCQueue, the initial capacity is rounded up to a power of two (and to 1 if it's
+// less than that)
+func NewSPSCQueue[T any](capacity int) *SPSCQueue[T] {
+	// capacity is at most 2^63-1 so c stops at 2^63 before it can overflow
+	c := uint64(1)
+	for c < uint64(max(capacity, 1)) {
+		c <<= 1
+	}
+	ring := newSPSCRing[T](c)
+	return &SPSCQueue[T]{producer: ring, consumer: ring}
+}
+
+// Push pushes an element to the back of the queue, it never blocks. Must only be called from the producer goroutine.
+func (q *SPSCQueue[T]) Push(el T) {
+	ring := q.producer
+	tail := ring.tail
+	if tail-atomic.LoadUint64(&ring.head) == uint64(len(ring.elements)) {
+		bigger := newSPSCRing[T](uint64(len(ring.elements)) * pushMultiplierOldVector)
+		// everything pushed to the old ring is published before the consumer can see the new one
+		atomic.StorePointer(&ring.next, unsafe.Pointer(bigger))
+		q.producer = bigger
+		ring = bigger
+		tail = 0
+	}
+	ring.elements[tail&ring.mask] = el
+	atomic.StoreUint64(&ring.tail, tail+1)
+}
+
+// Pop pops the element in the front of the queue, the boolean is false if the queue is empty. Must only be called from the consumer goroutine.
+func (q *SPSCQueue[T]) Pop() (T, bool) {
+	var defaul T
+	for {
+		ring := q.consumer
+		head := ring.head
+		if head != atomic.LoadUint64(&ring.tail) {
+			el := ring.elements[head&ring.mask]
+			ring.elements[head&ring.mask] = defaul
+			atomic.StoreUint64(&ring.head, head+1)
+			return el, true
+		}
+		next := atomic.LoadPointer(&ring.next)
+		if next == nil {
+			return defaul, false
+		}
+		// the producer might have pushed more elements before moving to the next ring
+		if head != atomic.LoadUint64(&ring.tail) {
+			continue
+		}
+		// the old ring is drained, free it
+		ring.elements = nil
+		q.consumer = (*spscRing[T])(next)
+	}
+}
+
+// Peek returns the element in the front of the queue without popping it. Must only be called from the consumer goroutine.
+func (q *SPSCQueue[T]) Peek() (T, bool) {
+	var defaul T
+	for {
+		ring := q.consumer
+		head := ring.head
+		if head != atomic.LoadUint64(&ring.tail) {
+			return ring.elements[head&ring.mask], true
+		}
+		next := atomic.LoadPointer(&ring.next)
+		if next == nil {
+			return defaul, false
+		}
+		if head != atomic.LoadUint64(&ring.tail) {
+			continue
+		}
+		ring.elements = nil
+		q.consumer = (*spscRing[T])(next)
+	}
+}
+
+// Len returns the number of elements in the queue, the producer might be pushing at the same time so
+// it's a lower bound. Must only be called from the consumer goroutine.
+func (q *SPSCQueue[T]) Len() int {
+	n := uint64(0)
+	for ring := q.consumer; ring != nil; ring = (*spscRing[T])(atomic.LoadPointer(&ring.next)) {
+		n += atomic.LoadUint64(&ring.tail) - atomic.LoadUint64(&ring.head)
+	}
+	return int(n)
+}
+
+// IsEmpty returns if the queue is empty. Must only be called from the consumer goroutine.
+func (q *SPSCQueue[T]) IsEmpty() bool {
+	return q.Len() == 0
+}
@@ -0,0 +1,273 @@
+package atone
+
+import "sync"
+
+// SyncVec is a Vec that can be used from multiple goroutines. Reads share a read lock and every
+// operation that can move elements between the old head and the new tail (pushes, pops, inserts...) runs
+// under the write lock, so a migration step is never observed half done.
+type SyncVec[T any] struct {
+	mu  sync.RWMutex
+	vec *Vec[T]
+}
+
+// NewSync returns a new SyncVec
+func NewSync[T any]() *SyncVec[T] {
+	return &SyncVec[T]{vec: New[T]()}
+}
+
+// SyncFrom returns a new SyncVec from a Slice
+func SyncFrom[T any](elements []T) *SyncVec[T] {
+	return &SyncVec[T]{vec: From(elements)}
+}
+
+// NewSyncWithCapacity is the equivalent of doing make([]T, 0, capacity)
+func NewSyncWithCapacity[T any](capacity uint64) *SyncVec[T] {
+	return &SyncVec[T]{vec: NewWithCapacity[T](capacity)}
+}
+
+// WithLock runs fn holding the write lock, so the whole batch of operations that fn does on the
+// Vec is atomic for the rest of the goroutines. The Vec must not be retained after fn returns.
+func (s *SyncVec[T]) WithLock(fn func(v *Vec[T])) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.vec)
+}
+
+// WithRLock runs fn holding the read lock, fn must only call methods that don't modify the Vec.
+func (s *SyncVec[T]) WithRLock(fn func(v *Vec[T])) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn(s.vec)
+}
+
+// Lookup returns an element, the boolean is false if the element does not exist.
+func (s *SyncVec[T]) Lookup(index int) (T, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vec.Lookup(index)
+}
+
+// Get returns the element in the specified index, can panic if it is outofbounds, if you don't want to panic on get, use Lookup
+func (s *SyncVec[T]) Get(index int) T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vec.Get(index)
+}
+
+// Set sets the element in the specified index, can panic if it is outofbounds
+func (s *SyncVec[T]) Set(index int, el T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vec.Set(index, el)
+}
+
+// Update calls fn with a pointer to the element in the specified index holding the write lock.
+// It replaces GetRef, which can't be offered safely because the pointer would outlive the lock.
+func (s *SyncVec[T]) Update(index int, fn func(el *T)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.vec.GetRef(index))
+}
+
+// Find02 tries to find not doing a continuous loop
+func (s *SyncVec[T]) Find02(el T, cb func(element T) bool) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vec.Find02(el, cb)
+}
+
+// Find finds doing a lookup in head and then in tail
+func (s *SyncVec[T]) Find(el T, cb func(element T) bool) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vec.Find(el, cb)
+}
+
+// FindMultithreaded finds an element with multithreading (with a lot of elements 1000000+)
+func (s *SyncVec[T]) FindMultithreaded(el T, cb func(T) bool) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vec.FindMultithreaded(el, cb)
+}
+
+// Insert .
+func (s *SyncVec[T]) Insert(el T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vec.Insert(el)
+}
+
+// Swap swaps elements in the structure
+func (s *SyncVec[T]) Swap(i int, j int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vec.Swap(i, j)
+}
+
+// Reverse inplace the array and empties the old head
+func (s *SyncVec[T]) Reverse() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vec.Reverse()
+}
+
+// Reserve the desired size inmemory to let space for nElements
+func (s *SyncVec[T]) Reserve(nElements int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vec.Reserve(nElements)
+}
+
+// Capacity is the equivalent of cap(elements)
+func (s *SyncVec[T]) Capacity() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vec.Capacity()
+}
+
+// Shrink ; the capacity will remain to atleast the length of the array (TODO)
+func (s *SyncVec[T]) Shrink(minCapacity int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vec.Shrink(minCapacity)
+}
+
+// Truncate only will mantain only the first 'n' elements in the array and the rest will be free'd
+func (s *SyncVec[T]) Truncate(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vec.Truncate(n)
+}
+
+// Len returns the number of elements stored in the array
+func (s *SyncVec[T]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vec.Len()
+}
+
+// IsEmpty returns if there is any element in the array or not
+func (s *SyncVec[T]) IsEmpty() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vec.IsEmpty()
+}
+
+// Clear empties the array
+func (s *SyncVec[T]) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vec.Clear()
+}
+
+// Contains returns true if the element is inside the array
+func (s *SyncVec[T]) Contains(el T, cb func(T) bool) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vec.Contains(el, cb)
+}
+
+// ContainsCmp returns true if the element is inside the array, will use the cmp func
+func (s *SyncVec[T]) ContainsCmp(el T, cmp func(arrayElement T, el T) bool) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vec.ContainsCmp(el, cmp)
+}
+
+// First returns the first element of the array, returns null if it is empty
+func (s *SyncVec[T]) First() T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vec.First()
+}
+
+// Last returns the last element of the array, returns null if it is empty
+func (s *SyncVec[T]) Last() T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vec.Last()
+}
+
+// PopFront pops the first element of the array, returns null if the array is empty
+func (s *SyncVec[T]) PopFront() T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.vec.PopFront()
+}
+
+// PopBack pops the last element of the array, returns null if the array is empty
+func (s *SyncVec[T]) PopBack() T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.vec.PopBack()
+}
+
+// Pop same as PopBack
+func (s *SyncVec[T]) Pop() T {
+	return s.PopBack()
+}
+
+// Iter generates an array of elements (allocates space for the iteration)
+func (s *SyncVec[T]) Iter() []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vec.Iter()
+}
+
+// Slice generates a slice slicing the array from start to end (end is not inclusive and start is)
+func (s *SyncVec[T]) Slice(start, end int) []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vec.Slice(start, end)
+}
+
+// Array creates a slice of this array
+func (s *SyncVec[T]) Array() []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vec.Array()
+}
+
+// SliceThis returns a new SyncVec with the specified slice (end non inclusive and start is inclusive)
+func (s *SyncVec[T]) SliceThis(start, end int) *SyncVec[T] {
+	return SyncFrom(s.Slice(start, end))
+}
+
+// ForEach iterates through the array doing a callback to the passed function, the read lock is held
+// during the whole iteration so fn must not modify the SyncVec
+func (s *SyncVec[T]) ForEach(fn func(el T, index int)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.vec.ForEach(fn)
+}
+
+// All returns an iterator over the indexes and elements of the SyncVec, the read lock is held during the whole
+// iteration so yield must not modify the SyncVec
+func (s *SyncVec[T]) All() func(yield func(index int, el T) bool) {
+	return func(yield func(index int, el T) bool) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		s.vec.All()(yield)
+	}
+}
+
+// Push pushes back an element into the array
+func (s *SyncVec[T]) Push(el T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vec.Push(el)
+}
+
+// Append is the equivalent of doing append(elements, toAppend...), all the elements are appended atomically
+func (s *SyncVec[T]) Append(el ...T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vec.Append(el...)
+}
+
+// Debug the vec
+func (s *SyncVec[T]) Debug() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vec.Debug()
+}
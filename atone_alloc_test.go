@@ -0,0 +1,103 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+func testAllocator(alloc atone.Allocator[int]) {
+	counting := atone.NewCountingAllocator(alloc)
+	v := atone.NewWithAllocator[int](counting)
+	ref := []int{}
+	for i := 0; i < 20000; i++ {
+		v.Push(i)
+		ref = append(ref, i)
+		if i%7 == 0 {
+			assert(v.PopFront() == ref[0])
+			ref = ref[1:]
+		}
+		// the Vec only holds the arrays of the migration, the rest are given back
+		assert(counting.Allocs()-counting.Frees() <= 2)
+	}
+	for i := range ref {
+		assert(v.Get(i) == ref[i])
+	}
+	v.Truncate(10)
+	assert(v.Len() == 10 && v.Get(9) == ref[9])
+	v.Free()
+	assert(v.IsEmpty())
+	assert(counting.Allocs() == counting.Frees() && counting.InUse() == 0)
+	v.Append(1, 2, 3)
+	assert(v.Get(2) == 3)
+	v.Free()
+	assert(counting.InUse() == 0)
+}
+
+func TestAllocators(t *testing.T) {
+	testAllocator(nil)
+	testAllocator(atone.NewPoolAllocator[int]())
+	testAllocator(atone.NewSizeClassAllocator[int](4))
+	testAllocator(atone.NewMmapAllocator[int]())
+}
+
+func TestSizeClassAllocatorReuses(t *testing.T) {
+	alloc := atone.NewSizeClassAllocator[int](1)
+	elements := alloc.Alloc(100)
+	assert(len(elements) == 0 && cap(elements) == 128)
+	elements = append(elements, 1)
+	alloc.Free(elements)
+	reused := alloc.Alloc(65)
+	assert(cap(reused) == 128 && &reused[:1][0] == &elements[0] && reused[:1][0] == 0)
+}
+
+func TestAllocatorIgnoresForeignArrays(t *testing.T) {
+	counting := atone.NewCountingAllocator[int](atone.NewMmapAllocator[int]())
+	v := atone.NewWithAllocator[int](counting)
+	// a Free of an array that wasn't mapped by the allocator does nothing
+	atone.NewMmapAllocator[int]().Free(make([]int, 4))
+	v.Append(1, 2, 3)
+	v.Reverse()
+	assert(v.Get(0) == 3)
+	v.Free()
+	assert(counting.Allocs() == counting.Frees() && counting.InUse() == 0)
+}
+
+func TestVecsRecycleArrays(t *testing.T) {
+	// every pooled Vec of the same type shares the pool, none of them can see the elements of another
+	done := make(chan bool)
+	for g := 0; g < 8; g++ {
+		go func(g int) {
+			ok := true
+			for round := 0; round < 20; round++ {
+				v := atone.NewPooled[[2]int]()
+				for i := 0; i < 3000; i++ {
+					v.Push([2]int{g, i})
+				}
+				for i := 0; i < v.Len(); i++ {
+					ok = ok && v.Get(i) == [2]int{g, i}
+				}
+				v.Free()
+			}
+			done <- ok
+		}(g)
+	}
+	for g := 0; g < 8; g++ {
+		assert(<-done)
+	}
+}
+
+func TestDefaultVecDoesNotRecycle(t *testing.T) {
+	v := atone.New[int]()
+	v.Push(1)
+	ref := v.GetRef(0)
+	for i := 0; i < 1000; i++ {
+		v.Push(i)
+	}
+	other := atone.New[int]()
+	for i := 0; i < 1000; i++ {
+		other.Push(-1)
+	}
+	// the array the pointer is in was left to the GC, no other Vec reuses it
+	assert(*ref == 1)
+}
@@ -0,0 +1,46 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+func TestBitset(t *testing.T) {
+	b := atone.NewBitset()
+	for i := uint64(0); i < 10000; i += 3 {
+		b.Set(i)
+	}
+	assert(b.Count() == 3334)
+	assert(b.Test(9999) && !b.Test(10000) && !b.Test(1<<40))
+	b.Clear(3)
+	assert(!b.Test(3))
+	next, ok := b.NextSet(1)
+	assert(ok && next == 6)
+	next, ok = b.NextSet(9998)
+	assert(ok && next == 9999)
+	_, ok = b.NextSet(10000)
+	assert(!ok)
+}
+
+func TestBitsetOps(t *testing.T) {
+	a, b := atone.NewBitset(), atone.NewBitset()
+	for _, i := range []uint64{1, 2, 100} {
+		a.Set(i)
+	}
+	for _, i := range []uint64{2, 100, 500} {
+		b.Set(i)
+	}
+	and := a.Clone()
+	and.And(b)
+	assert(and.Count() == 2 && and.Test(2) && and.Test(100))
+	or := a.Clone()
+	or.Or(b)
+	assert(or.Count() == 4 && or.Test(500))
+	xor := a.Clone()
+	xor.Xor(b)
+	assert(xor.Count() == 2 && xor.Test(1) && xor.Test(500))
+	andNot := a.Clone()
+	andNot.AndNot(b)
+	assert(andNot.Count() == 1 && andNot.Test(1))
+}
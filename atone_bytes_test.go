@@ -0,0 +1,54 @@
+package test
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+func TestByteVecReadWrite(t *testing.T) {
+	payload := make([]byte, 100000)
+	rand.New(rand.NewSource(1)).Read(payload)
+	b := atone.NewByteVec()
+	for i := 0; i < len(payload); i += 777 {
+		b.Write(payload[i:min(i+777, len(payload))])
+	}
+	assert(b.Len() == len(payload))
+	assert(iotest.TestReader(atone.ByteVecFrom(b.Bytes()), payload) == nil)
+
+	first, _ := b.ReadByte()
+	assert(first == payload[0])
+	assert(b.UnreadByte() == nil)
+	assert(b.UnreadByte() != nil)
+	read, err := io.ReadAll(b)
+	assert(err == nil && bytes.Equal(read, payload))
+	_, err = b.ReadByte()
+	assert(err == io.EOF)
+}
+
+func TestByteVecReadFromWriteTo(t *testing.T) {
+	payload := bytes.Repeat([]byte("atone "), 50000)
+	b := atone.NewByteVec()
+	b.WriteString("head ")
+	n, err := b.ReadFrom(iotest.OneByteReader(bytes.NewReader(payload[:1000])))
+	assert(err == nil && n == 1000)
+	n, err = b.ReadFrom(bytes.NewReader(payload[1000:]))
+	assert(err == nil && int(n) == len(payload)-1000)
+	b.WriteByte('!')
+	out := bytes.Buffer{}
+	written, err := b.WriteTo(&out)
+	assert(err == nil && int(written) == len(payload)+6)
+	assert(out.String() == "head "+string(payload)+"!")
+	assert(b.Len() == 0)
+}
+
+func min(n, n2 int) int {
+	if n < n2 {
+		return n
+	}
+	return n2
+}
@@ -0,0 +1,66 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+func TestChanRoundTrip(t *testing.T) {
+	nItems := 100
+	arr := atone.New[int]()
+	ch, done := arr.PushChan(context.Background())
+	for i := 0; i < nItems; i++ {
+		ch <- i
+	}
+	close(ch)
+	assert(<-done == 0)
+	assert(arr.Len() == nItems)
+
+	out := make(chan int)
+	go func() {
+		defer close(out)
+		assert(arr.SendTo(context.Background(), out) == nil)
+	}()
+	received := atone.FromChan(context.Background(), out)
+	assert(received.Len() == nItems)
+	for i := 0; i < nItems; i++ {
+		assert(received.Get(i) == i)
+	}
+}
+
+func TestChanCancel(t *testing.T) {
+	arr := atone.From([]int{1, 2, 3})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert(arr.SendTo(ctx, make(chan int)) == context.Canceled)
+	assert(atone.FromChan(ctx, make(chan int)).Len() == 0)
+	ch, done := arr.PushChan(ctx)
+	// the values sent after ctx is done don't block and are not pushed
+	ch <- 4
+	close(ch)
+	assert(<-done == 1)
+	assert(arr.Len() == 3)
+}
+
+func TestPushChanCancelWhileSending(t *testing.T) {
+	arr := atone.New[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, done := arr.PushChan(ctx)
+	for i := 0; i < 10; i++ {
+		ch <- i
+	}
+	cancel()
+	for i := 10; i < 20; i++ {
+		ch <- i
+	}
+	close(ch)
+	discarded := <-done
+	// everything sent before cancel is there, at most one value raced with it, and the rest were discarded
+	assert(arr.Len() == 10 || arr.Len() == 11)
+	assert(arr.Len()+discarded == 20)
+	for i := 0; i < arr.Len(); i++ {
+		assert(arr.Get(i) == i)
+	}
+}
@@ -0,0 +1,166 @@
+package test
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+type auditEntry struct {
+	User   string
+	Action string
+	At     int64
+}
+
+func TestDurableVecReplay(t *testing.T) {
+	dir := t.TempDir()
+	d, err := atone.OpenDurableVec[auditEntry](dir, atone.GobCodec[auditEntry]{})
+	assert(err == nil)
+	for i := 0; i < 100; i++ {
+		assert(d.Push(auditEntry{User: "user", Action: "login", At: int64(i)}) == nil)
+	}
+	popped, err := d.PopBack()
+	assert(err == nil && popped.At == 99)
+	assert(d.Truncate(50) == nil)
+	assert(d.Close() == nil)
+
+	d, err = atone.OpenDurableVec[auditEntry](dir, atone.GobCodec[auditEntry]{})
+	assert(err == nil && d.Len() == 50)
+	for i := 0; i < 50; i++ {
+		assert(d.Get(i).At == int64(i) && d.Get(i).User == "user")
+	}
+	assert(d.Close() == nil)
+}
+
+func TestDurableVecCompaction(t *testing.T) {
+	dir := t.TempDir()
+	opts := atone.DurableOptions{Sync: atone.SyncBatch, BatchSize: 8, CompactEvery: 100}
+	d, err := atone.OpenDurableVecWithOptions[uint64](dir, atone.FixedCodec[uint64]{}, opts)
+	assert(err == nil)
+	for i := 0; i < 1050; i++ {
+		assert(d.Push(uint64(i)) == nil)
+	}
+	assert(d.Close() == nil)
+	info, err := os.Stat(filepath.Join(dir, "wal"))
+	// the log only has the records since the last compaction, which happened at 800 elements
+	assert(err == nil && info.Size() == 250*29)
+
+	// a crash between writing the snapshot and emptying the log leaves records the snapshot already has
+	wal, err := os.ReadFile(filepath.Join(dir, "wal"))
+	assert(err == nil)
+	d, err = atone.OpenDurableVecWithOptions[uint64](dir, atone.FixedCodec[uint64]{}, opts)
+	assert(err == nil && d.Compact() == nil && d.Close() == nil)
+	assert(os.WriteFile(filepath.Join(dir, "wal"), wal, 0o644) == nil)
+
+	d, err = atone.OpenDurableVecWithOptions[uint64](dir, atone.FixedCodec[uint64]{}, opts)
+	assert(err == nil && d.Len() == 1050)
+	for i := 0; i < 1050; i++ {
+		assert(d.Get(i) == uint64(i))
+	}
+	assert(d.Close() == nil)
+}
+
+func TestDurableVecInterval(t *testing.T) {
+	dir := t.TempDir()
+	opts := atone.DurableOptions{Sync: atone.SyncInterval, Interval: time.Millisecond}
+	d, err := atone.OpenDurableVecWithOptions[int32](dir, atone.FixedCodec[int32]{}, opts)
+	assert(err == nil)
+	for i := 0; i < 500; i++ {
+		assert(d.Push(int32(i)) == nil)
+	}
+	assert(d.Close() == nil)
+	d, err = atone.OpenDurableVec[int32](dir, atone.FixedCodec[int32]{})
+	assert(err == nil && d.Len() == 500 && d.Get(499) == 499)
+	assert(d.Close() == nil)
+}
+
+func TestDurableVecTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	d, err := atone.OpenDurableVec[uint64](dir, atone.FixedCodec[uint64]{})
+	assert(err == nil)
+	for i := 0; i < 10; i++ {
+		assert(d.Push(uint64(i)) == nil)
+	}
+	assert(d.Close() == nil)
+	path := filepath.Join(dir, "wal")
+	wal, err := os.ReadFile(path)
+	assert(err == nil)
+	recordSize := len(wal) / 10
+
+	for _, torn := range [][]byte{
+		// half of the last record was written
+		wal[:len(wal)-recordSize/2],
+		// the header of the last record was cut off
+		wal[:len(wal)-recordSize+5],
+		// the file grew but the record was never written
+		append(append([]byte{}, wal...), make([]byte, 100)...),
+		// the header of the last record was written but not all of its body
+		append(append([]byte{}, wal[:len(wal)-9]...), make([]byte, 9)...),
+		append(append([]byte{}, wal[:len(wal)-3]...), 1, 2, 3),
+	} {
+		assert(os.WriteFile(path, torn, 0o644) == nil)
+		d, err := atone.OpenDurableVec[uint64](dir, atone.FixedCodec[uint64]{})
+		assert(err == nil && (d.Len() == 9 || d.Len() == 10))
+		// the torn record is gone and the log goes on after the last good one
+		assert(d.Push(100) == nil && d.Close() == nil)
+		d, err = atone.OpenDurableVec[uint64](dir, atone.FixedCodec[uint64]{})
+		assert(err == nil && d.Get(d.Len()-1) == 100)
+		assert(d.Close() == nil)
+		assert(os.WriteFile(path, wal, 0o644) == nil)
+	}
+
+	// a broken record in the middle is not a crash
+	broken := append([]byte{}, wal...)
+	broken[recordSize*3+20] ^= 0xff
+	assert(os.WriteFile(path, broken, 0o644) == nil)
+	_, err = atone.OpenDurableVec[uint64](dir, atone.FixedCodec[uint64]{})
+	assert(errors.Is(err, atone.ErrDurableCorrupt))
+
+	// neither is a broken length in the middle
+	brokenLength := append([]byte{}, wal...)
+	brokenLength[recordSize*3+2] ^= 0xff
+	assert(os.WriteFile(path, brokenLength, 0o644) == nil)
+	_, err = atone.OpenDurableVec[uint64](dir, atone.FixedCodec[uint64]{})
+	assert(errors.Is(err, atone.ErrDurableCorrupt))
+	// the log is left as it is
+	data, err := os.ReadFile(path)
+	assert(err == nil && string(data) == string(brokenLength))
+}
+
+func TestDurableVecInvalidRecords(t *testing.T) {
+	dir := t.TempDir()
+	d, err := atone.OpenDurableVec[uint64](dir, atone.FixedCodec[uint64]{})
+	assert(err == nil)
+	for i := 0; i < 10; i++ {
+		assert(d.Push(uint64(i)) == nil)
+	}
+	assert(errors.Is(d.Truncate(-1), atone.ErrDurableTruncate) && d.Len() == 10)
+	assert(d.Close() == nil)
+	d, err = atone.OpenDurableVec[uint64](dir, atone.FixedCodec[uint64]{})
+	assert(err == nil && d.Len() == 10)
+	assert(d.Close() == nil)
+
+	// a record with valid checksums that truncates past the end can't be applied
+	body := make([]byte, 17)
+	binary.LittleEndian.PutUint64(body[0:8], 11)
+	body[8] = 3
+	binary.LittleEndian.PutUint64(body[9:17], 1000)
+	table := crc32.MakeTable(crc32.Castagnoli)
+	record := make([]byte, 12, 12+len(body))
+	binary.LittleEndian.PutUint32(record[0:4], uint32(len(body)))
+	binary.LittleEndian.PutUint32(record[4:8], crc32.Checksum(body, table))
+	binary.LittleEndian.PutUint32(record[8:12], crc32.Checksum(record[0:8], table))
+	record = append(record, body...)
+	path := filepath.Join(dir, "wal")
+	wal, err := os.ReadFile(path)
+	assert(err == nil)
+	assert(os.WriteFile(path, append(wal, record...), 0o644) == nil)
+	_, err = atone.OpenDurableVec[uint64](dir, atone.FixedCodec[uint64]{})
+	assert(errors.Is(err, atone.ErrDurableCorrupt))
+}
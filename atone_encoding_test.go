@@ -0,0 +1,87 @@
+package test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+type encodingPoint struct {
+	X, Y int
+	Name string
+}
+
+func TestVecMarshalBinaryFixed(t *testing.T) {
+	v := atone.New[float64]()
+	for i := 0; i < 10000; i++ {
+		v.Push(float64(i) / 2)
+	}
+	data, err := v.MarshalBinary()
+	assert(err == nil)
+	assert(len(data) == 12+10000*8)
+	decoded := atone.New[float64]()
+	decoded.Push(-1)
+	assert(decoded.UnmarshalBinary(data) == nil)
+	assert(decoded.Len() == 10000)
+	for i := 0; i < 10000; i++ {
+		assert(decoded.Get(i) == float64(i)/2)
+	}
+	// the kind is checked even if the size is the same
+	assert(atone.New[int64]().UnmarshalBinary(data) == atone.ErrVecEncoding)
+	assert(atone.New[float64]().UnmarshalBinary(data[:len(data)-1]) == atone.ErrVecEncoding)
+	assert(atone.New[float64]().UnmarshalBinary(data[:5]) == atone.ErrVecEncoding)
+
+	empty, err := atone.New[uint8]().MarshalBinary()
+	assert(err == nil)
+	decodedEmpty := atone.From([]uint8{1, 2})
+	assert(decodedEmpty.UnmarshalBinary(empty) == nil && decodedEmpty.IsEmpty())
+}
+
+func TestVecMarshalBinaryGob(t *testing.T) {
+	v := atone.New[encodingPoint]()
+	for i := 0; i < 1000; i++ {
+		v.Push(encodingPoint{X: i, Y: -i, Name: "point"})
+	}
+	data, err := v.MarshalBinary()
+	assert(err == nil)
+	decoded := atone.New[encodingPoint]()
+	assert(decoded.UnmarshalBinary(data) == nil)
+	assert(decoded.Len() == 1000)
+	for i := 0; i < 1000; i++ {
+		assert(decoded.Get(i) == v.Get(i))
+	}
+	assert(atone.New[float64]().UnmarshalBinary(data) == atone.ErrVecEncoding)
+	assert(decoded.UnmarshalBinary(data[:len(data)-3]) != nil)
+	assert(decoded.IsEmpty())
+}
+
+func TestVecGobEncoder(t *testing.T) {
+	type document struct {
+		Title string
+		Lines *atone.Vec[string]
+		Sizes *atone.Vec[int32]
+	}
+	doc := document{Title: "doc", Lines: atone.From([]string{"a", "b", "c"}), Sizes: atone.From([]int32{1, 2, 3})}
+	var buf bytes.Buffer
+	assert(gob.NewEncoder(&buf).Encode(doc) == nil)
+	var decoded document
+	assert(gob.NewDecoder(&buf).Decode(&decoded) == nil)
+	assert(decoded.Title == "doc")
+	assert(decoded.Lines.Len() == 3 && decoded.Lines.Get(2) == "c")
+	assert(decoded.Sizes.Len() == 3 && decoded.Sizes.Get(0) == 1)
+}
+
+func TestVecUnmarshalBinaryBool(t *testing.T) {
+	v := atone.From([]bool{true, false, true})
+	data, err := v.MarshalBinary()
+	assert(err == nil)
+	decoded := atone.New[bool]()
+	assert(decoded.UnmarshalBinary(data) == nil)
+	assert(decoded.Len() == 3 && decoded.Get(0) && !decoded.Get(1) && decoded.Get(2))
+
+	data[len(data)-1] = 2
+	assert(errors.Is(decoded.UnmarshalBinary(data), atone.ErrVecEncoding))
+}
@@ -0,0 +1,151 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+type fileRecord struct {
+	ID    uint64
+	Value float64
+}
+
+func TestFileVec(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records")
+	v, err := atone.OpenFileVec[fileRecord](path)
+	assert(err == nil && v.IsEmpty())
+	nItems := 5000
+	for i := 0; i < nItems; i++ {
+		assert(v.Push(fileRecord{ID: uint64(i), Value: float64(i) / 2}) == nil)
+	}
+	v.Set(10, fileRecord{ID: 10, Value: -1})
+	assert(v.Close() == nil)
+
+	v, err = atone.OpenFileVec[fileRecord](path)
+	assert(err == nil && v.Len() == nItems)
+	for i := 0; i < nItems; i++ {
+		el := v.Get(i)
+		assert(el.ID == uint64(i))
+		assert(i == 10 && el.Value == -1 || i != 10 && el.Value == float64(i)/2)
+	}
+	assert(v.PopBack().ID == uint64(nItems-1))
+	v.Truncate(100)
+	assert(v.Len() == 100)
+	assert(v.Sync() == nil)
+	assert(v.Close() == nil)
+
+	v, err = atone.OpenFileVec[fileRecord](path)
+	assert(err == nil && v.Len() == 100 && v.Get(99).ID == 99)
+	assert(v.Close() == nil)
+}
+
+func TestFileVecChecks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "numbers")
+	v, err := atone.OpenFileVec[uint64](path)
+	assert(err == nil)
+	assert(v.Push(1) == nil && v.Push(2) == nil)
+	assert(v.Close() == nil)
+
+	_, err = atone.OpenFileVec[uint32](path)
+	assert(errors.Is(err, atone.ErrFileVecElementSize))
+
+	// the newest slot of the header is corrupted, the one of the Sync before it is used
+	data, err := os.ReadFile(path)
+	assert(err == nil)
+	data[16] ^= 0xff
+	assert(os.WriteFile(path, data, 0o644) == nil)
+	v, err = atone.OpenFileVec[uint64](path)
+	assert(err == nil && v.IsEmpty())
+	assert(v.Close() == nil)
+
+	// after the Close above both slots have the length 0, corrupt both of them
+	data, err = os.ReadFile(path)
+	assert(err == nil)
+	data[16] ^= 0xff
+	data[48] ^= 0xff
+	assert(os.WriteFile(path, data, 0o644) == nil)
+	_, err = atone.OpenFileVec[uint64](path)
+	assert(errors.Is(err, atone.ErrFileVecChecksum))
+
+	other := filepath.Join(dir, "other")
+	assert(os.WriteFile(other, make([]byte, 128), 0o644) == nil)
+	_, err = atone.OpenFileVec[uint64](other)
+	assert(errors.Is(err, atone.ErrFileVecFormat))
+}
+
+func TestFileVecUnsyncedChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "numbers")
+	v, err := atone.OpenFileVec[uint64](path)
+	assert(err == nil)
+	for i := 0; i < 10; i++ {
+		assert(v.Push(uint64(i)) == nil)
+	}
+	assert(v.Sync() == nil)
+
+	// the elements changed after the Sync reach the file without a new header, like after a crash
+	data, err := os.ReadFile(path)
+	assert(err == nil)
+	data[64] ^= 0xff
+	assert(os.WriteFile(path, data, 0o644) == nil)
+	other, err := atone.OpenFileVec[uint64](path)
+	assert(err == nil && other.Len() == 10 && other.Get(0) == 0xff)
+	assert(other.Close() == nil)
+	assert(v.Close() == nil)
+}
+
+func TestFileVecGrowKeepsReferences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "numbers")
+	v, err := atone.OpenFileVec[uint32](path)
+	assert(err == nil)
+	assert(v.Push(7) == nil)
+	ref := v.GetRef(0)
+	for i := 1; i < 3000; i++ {
+		assert(v.Push(uint32(i)) == nil)
+	}
+	*ref = 8
+	assert(v.Get(0) == 8 && v.Capacity() == 4096)
+	assert(v.Close() == nil)
+
+	// the file is opened with its whole capacity in the first part and grows from there
+	v, err = atone.OpenFileVec[uint32](path)
+	assert(err == nil && v.Len() == 3000)
+	for i := 3000; i < 10000; i++ {
+		assert(v.Push(uint32(i)) == nil)
+	}
+	elements := v.Iter()
+	assert(len(elements) == 10000 && elements[0] == 8)
+	n := 0
+	v.All()(func(index int, el uint32) bool {
+		assert(index == 0 || el == uint32(index))
+		n++
+		return true
+	})
+	assert(n == 10000)
+	v.Truncate(5000)
+	assert(v.Len() == 5000 && v.Get(4999) == 4999)
+	assert(v.Close() == nil)
+}
+
+func TestFileVecSetInEverySegment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bytes")
+	v, err := atone.OpenFileVec[byte](path)
+	assert(err == nil)
+	for i := 0; i < 1500; i++ {
+		assert(v.Push(0) == nil)
+	}
+	// 0 is in the first segment and 1000 in the second one, where there is no mmap each one is written back
+	// on its own and none of them must overwrite the other
+	v.Set(0, 42)
+	v.Set(1000, 43)
+	assert(v.Close() == nil)
+
+	v, err = atone.OpenFileVec[byte](path)
+	assert(err == nil && v.Len() == 1500)
+	assert(v.Get(0) == 42 && v.Get(1000) == 43)
+	assert(v.Close() == nil)
+}
@@ -0,0 +1,78 @@
+package test
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+func TestHeap(t *testing.T) {
+	nItems := 2000
+	r := rand.New(rand.NewSource(1))
+	h := atone.NewHeap(func(a, b int) bool { return a < b })
+	elements := make([]int, 0, nItems)
+	for i := 0; i < nItems; i++ {
+		el := r.Intn(nItems)
+		h.Push(el)
+		elements = append(elements, el)
+	}
+	// remove a few from the middle and put them back
+	for i := 0; i < 100; i++ {
+		h.Push(h.Remove(r.Intn(h.Len())))
+	}
+	sort.Ints(elements)
+	for i := 0; !h.IsEmpty(); i++ {
+		assert(h.Pop() == elements[i])
+	}
+	assert(h.Pop() == 0)
+	sorted := atone.HeapFrom([]int{5, 3, 9, 1}, func(a, b int) bool { return a < b })
+	sorted.Update(3, 0)
+	assert(sorted.Peek() == 0)
+	sorted.Pop()
+	assert(sorted.Pop() == 1 && sorted.Pop() == 3 && sorted.Pop() == 9)
+}
+
+func TestIndexedHeapDecreaseKey(t *testing.T) {
+	h := atone.NewIndexedHeap(func(a, b int) bool { return a < b })
+	ids := make([]int, 0)
+	for i := 0; i < 100; i++ {
+		ids = append(ids, h.Push(100+i))
+	}
+	h.Update(ids[50], 1)
+	el, id := h.Peek()
+	assert(el == 1 && id == ids[50])
+	assert(h.Remove(ids[0]) == 100)
+	assert(!h.Contains(ids[0]))
+	reused := h.Push(0)
+	assert(reused == ids[0])
+	el, id = h.Pop()
+	assert(el == 0 && id == reused)
+	el, id = h.Pop()
+	assert(el == 1 && id == ids[50])
+	previous := -1
+	for !h.IsEmpty() {
+		el, _ := h.Pop()
+		assert(el > previous)
+		previous = el
+	}
+	_, id = h.Pop()
+	assert(id == -1)
+}
+
+func TestHeapInvalidPositionsAndIds(t *testing.T) {
+	h := atone.NewIndexedHeap(func(a, b int) bool { return a < b })
+	_, id := h.Pop()
+	assert(id == -1 && !h.Contains(id))
+	_, ok := h.Get(id)
+	assert(!ok && h.Remove(id) == 0)
+
+	heap := atone.NewHeap(func(a, b int) bool { return a < b })
+	for _, i := range []int{0, -1} {
+		func() {
+			defer func() { assert(recover() == "atone: heap position out of range") }()
+			heap.Remove(i)
+		}()
+	}
+}
@@ -0,0 +1,30 @@
+package test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+func TestInterner(t *testing.T) {
+	nItems := 5000
+	in := atone.NewInterner()
+	for i := 0; i < nItems; i++ {
+		assert(in.Intern(strconv.Itoa(i)) == uint32(i))
+	}
+	for i := 0; i < nItems; i++ {
+		assert(in.Intern(strconv.Itoa(i)) == uint32(i))
+		s, ok := in.Lookup(uint32(i))
+		assert(ok && s == strconv.Itoa(i))
+	}
+	assert(in.Len() == nItems)
+	_, ok := in.Lookup(uint32(nItems))
+	assert(!ok)
+	_, ok = in.ID("missing")
+	assert(!ok && in.Len() == nItems)
+	line := []byte("label=value")
+	id := in.Intern(string(line[6:]))
+	line[6] = 'X'
+	assert(in.Get(id) == "value")
+}
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+func TestVecJSON(t *testing.T) {
+	v := atone.New[int]()
+	for i := 0; i < 1000; i++ {
+		v.Push(i)
+	}
+	data, err := json.Marshal(v)
+	assert(err == nil)
+	var expected []int
+	assert(json.Unmarshal(data, &expected) == nil && len(expected) == 1000)
+	for i := range expected {
+		assert(expected[i] == i)
+	}
+	decoded := atone.From([]int{-1})
+	assert(json.Unmarshal(data, decoded) == nil)
+	assert(decoded.Len() == 1000 && decoded.Get(999) == 999)
+
+	empty, err := json.Marshal(atone.New[string]())
+	assert(err == nil && string(empty) == "[]")
+
+	type document struct {
+		Tags *atone.Vec[string] `json:"tags"`
+	}
+	var doc document
+	assert(json.Unmarshal([]byte(`{"tags": ["a", "b"]}`), &doc) == nil)
+	assert(doc.Tags.Len() == 2 && doc.Tags.Get(1) == "b")
+	assert(json.Unmarshal([]byte(`{"tags": null}`), &doc) == nil && doc.Tags == nil)
+	err = json.Unmarshal([]byte(`{"tags": {"a": 1}}`), &doc)
+	assert(err != nil)
+}
+
+func TestVecStreamingJSON(t *testing.T) {
+	v := atone.New[encodingPoint]()
+	for i := 0; i < 100; i++ {
+		v.Push(encodingPoint{X: i, Y: i * i, Name: "p"})
+	}
+	var buf bytes.Buffer
+	assert(v.EncodeJSON(&buf) == nil)
+	assert(strings.HasPrefix(buf.String(), `[{"X":0,"Y":0,"Name":"p"},{"X":1`))
+	decoded := atone.New[encodingPoint]()
+	assert(decoded.DecodeJSON(&buf) == nil)
+	assert(decoded.Len() == 100 && decoded.Get(99) == v.Get(99))
+
+	assert(decoded.DecodeJSON(strings.NewReader(`{"X": 1}`)) == atone.ErrVecJSON)
+	assert(decoded.DecodeJSON(strings.NewReader(`[{"X": 1}, {"X":`)) != nil)
+	assert(decoded.IsEmpty())
+}
+
+// countedJSON counts how many times it's marshaled
+type countedJSON struct {
+	n *int
+}
+
+func (c countedJSON) MarshalJSON() ([]byte, error) {
+	*c.n++
+	return []byte("1234567"), nil
+}
+
+type failingWriter struct{}
+
+var errWrite = errors.New("write")
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errWrite
+}
+
+func TestVecEncodeJSONWriteError(t *testing.T) {
+	n := 0
+	v := atone.New[countedJSON]()
+	for i := 0; i < 100000; i++ {
+		v.Push(countedJSON{n: &n})
+	}
+	assert(errors.Is(v.EncodeJSON(failingWriter{}), errWrite))
+	// it stops once the buffer is written for the first time
+	assert(n < 1000)
+}
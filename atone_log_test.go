@@ -0,0 +1,39 @@
+package test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+func TestAppendLogConcurrent(t *testing.T) {
+	nGoroutines := 8
+	nItems := 5000
+	l := atone.NewAppendLogWithCapacity[int](3)
+	wg := sync.WaitGroup{}
+	for g := 0; g < nGoroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < nItems; i++ {
+				el := g*nItems + i
+				index := l.Append(el)
+				got, ok := l.Get(index)
+				assert(ok && got == el)
+			}
+		}(g)
+	}
+	wg.Wait()
+	assert(l.Len() == nGoroutines*nItems)
+	seen := make([]bool, nGoroutines*nItems)
+	n := 0
+	l.ForEach(func(el int, _ int) {
+		assert(!seen[el])
+		seen[el] = true
+		n++
+	})
+	assert(n == nGoroutines*nItems)
+	_, ok := l.Get(nGoroutines * nItems)
+	assert(!ok)
+}
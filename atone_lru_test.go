@@ -0,0 +1,51 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+func TestLRU(t *testing.T) {
+	evicted := make([]int, 0)
+	l := atone.NewLRU(3, func(key int, value string) { evicted = append(evicted, key) })
+	assert(!l.Put(1, "a"))
+	assert(!l.Put(2, "b"))
+	assert(!l.Put(3, "c"))
+	v, ok := l.Get(1)
+	assert(ok && v == "a")
+	// 2 is the least recently used one now
+	assert(l.Put(4, "d"))
+	assert(len(evicted) == 1 && evicted[0] == 2 && !l.Contains(2))
+	// peek doesn't refresh 3
+	v, ok = l.Peek(3)
+	assert(ok && v == "c")
+	l.Put(5, "e")
+	assert(evicted[1] == 3)
+	assert(l.Remove(1) && !l.Remove(1))
+	l.Put(6, "f")
+	assert(l.Len() == 3 && len(evicted) == 2)
+	key, _, _ := l.Oldest()
+	assert(key == 4)
+	keys := make([]int, 0)
+	l.ForEach(func(key int, _ string) { keys = append(keys, key) })
+	assert(len(keys) == 3 && keys[0] == 6 && keys[1] == 5 && keys[2] == 4)
+}
+
+func TestLRUChurn(t *testing.T) {
+	nItems := 10000
+	l := atone.NewLRU[int, int](100, nil)
+	for i := 0; i < nItems; i++ {
+		l.Put(i, i)
+		if i%7 == 0 {
+			l.Remove(i - 3)
+		}
+	}
+	assert(l.Len() <= 100)
+	for i := nItems - 50; i < nItems; i++ {
+		v, ok := l.Get(i)
+		assert(!ok || v == i)
+	}
+	v, ok := l.Get(nItems - 1)
+	assert(ok && v == nItems-1)
+}
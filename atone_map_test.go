@@ -0,0 +1,76 @@
+package test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+func TestMap(t *testing.T) {
+	nItems := 10000
+	m := atone.NewMap[int, int]()
+	for i := 0; i < nItems; i++ {
+		m.Set(i, i*2)
+		assert(m.Len() == i+1)
+		// keys inserted before the current growth must still be found
+		v, ok := m.Get(i / 2)
+		assert(ok && v == i/2*2)
+	}
+	for i := 0; i < nItems; i += 2 {
+		assert(m.Delete(i))
+	}
+	assert(!m.Delete(0))
+	assert(m.Len() == nItems/2)
+	for i := 0; i < nItems; i++ {
+		_, ok := m.Get(i)
+		assert(ok == (i%2 == 1))
+	}
+	sum := 0
+	m.ForEach(func(k int, v int) {
+		assert(v == k*2)
+		sum++
+	})
+	assert(sum == nItems/2)
+}
+
+func TestMapKeys(t *testing.T) {
+	type point struct {
+		x, y float64
+		name string
+	}
+	m := atone.NewMap[point, string]()
+	for i := 0; i < 100; i++ {
+		m.Set(point{x: float64(i), name: "p"}, "v")
+	}
+	_, ok := m.Get(point{x: 50, name: "p"})
+	assert(ok)
+	_, ok = m.Get(point{x: 50, name: "q"})
+	assert(!ok)
+
+	s := atone.NewMap[string, int]()
+	s.Set("a", 1)
+	s.Set("a", 2)
+	v, _ := s.Get("a")
+	assert(v == 2 && s.Len() == 1)
+
+	f := atone.NewMap[float64, int]()
+	zero := 0.0
+	f.Set(zero, 1)
+	f.Set(-zero, 2)
+	v, _ = f.Get(0)
+	assert(v == 2 && f.Len() == 1)
+}
+
+func TestMapZeroValue(t *testing.T) {
+	var m atone.Map[string, int]
+	_, ok := m.Get("a")
+	assert(!ok && !m.Delete("a") && m.IsEmpty())
+	m.Clear()
+	for i := 0; i < 100; i++ {
+		m.Set(strconv.Itoa(i), i)
+	}
+	assert(m.Len() == 100)
+	value, ok := m.Get("42")
+	assert(ok && value == 42)
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+type manualPoint struct {
+	X, Y int32
+	Tag  [4]byte
+}
+
+func TestManualVec(t *testing.T) {
+	nItems := 10000
+	v := atone.NewManualVec[manualPoint]()
+	defer v.Free()
+	for i := 0; i < nItems; i++ {
+		v.Push(manualPoint{X: int32(i), Y: int32(-i)})
+		assert(v.Len() == i+1)
+		assert(v.Get(i/2).X == int32(i/2))
+	}
+	for i := 0; i < nItems; i++ {
+		p := v.Get(i)
+		assert(p.X == int32(i) && p.Y == int32(-i))
+	}
+	v.Set(3, manualPoint{X: 42})
+	assert(v.Get(3).X == 42)
+	v.GetRef(4).Y = 7
+	assert(v.Get(4).Y == 7)
+	for i := nItems - 1; i >= nItems/2; i-- {
+		assert(v.PopBack().X == int32(i))
+	}
+	assert(v.Len() == nItems/2)
+	elements := v.Iter()
+	assert(len(elements) == nItems/2 && elements[nItems/2-1].X == int32(nItems/2-1))
+	_, ok := v.Lookup(nItems / 2)
+	assert(!ok)
+	v.Free()
+	assert(v.IsEmpty() && v.Capacity() == 0)
+	v.Push(manualPoint{X: 1})
+	assert(v.Get(0).X == 1)
+}
+
+func TestManualVecPopWhileGrowing(t *testing.T) {
+	v := atone.NewManualVecWithCapacity[int](8)
+	defer v.Free()
+	ref := []int{}
+	for i := 0; i < 1000; i++ {
+		v.Push(i)
+		ref = append(ref, i)
+		if i%3 == 0 {
+			assert(v.PopBack() == ref[len(ref)-1])
+			ref = ref[:len(ref)-1]
+		}
+		for j := range ref {
+			assert(v.Get(j) == ref[j])
+		}
+	}
+}
+
+func TestManualVecRejectsPointers(t *testing.T) {
+	defer func() {
+		assert(recover() != nil)
+	}()
+	atone.NewManualVec[struct{ S string }]()
+}
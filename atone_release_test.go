@@ -0,0 +1,108 @@
+package test
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+type bigStruct struct {
+	payload [1024]byte
+}
+
+// waitCollected runs the GC until n objects have been finalized or it takes too long
+func waitCollected(collected *int64, n int64) bool {
+	for i := 0; i < 100; i++ {
+		runtime.GC()
+		if atomic.LoadInt64(collected) >= n {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestPoppedElementsAreCollected(t *testing.T) {
+	var collected int64
+	v := atone.New[*bigStruct]()
+	nItems := 1000
+	for i := 0; i < nItems; i++ {
+		b := &bigStruct{}
+		runtime.SetFinalizer(b, func(*bigStruct) { atomic.AddInt64(&collected, 1) })
+		v.Push(b)
+	}
+	for i := 0; i < nItems/2; i++ {
+		v.PopFront()
+		v.PopBack()
+	}
+	assert(v.IsEmpty())
+	assert(waitCollected(&collected, int64(nItems)))
+	runtime.KeepAlive(v)
+}
+
+func TestClearZeroesIncrementally(t *testing.T) {
+	var collected int64
+	v := atone.New[*bigStruct]()
+	nItems := 1000
+	for i := 0; i < nItems; i++ {
+		b := &bigStruct{}
+		runtime.SetFinalizer(b, func(*bigStruct) { atomic.AddInt64(&collected, 1) })
+		v.Push(b)
+	}
+	v.Clear()
+	assert(v.IsEmpty())
+	// every change after Clear zeroes a few slots
+	for i := 0; i < nItems; i++ {
+		v.Push(nil)
+		assert(v.Len() == i+1 && v.Get(i) == nil)
+	}
+	assert(waitCollected(&collected, int64(nItems)))
+	runtime.KeepAlive(v)
+}
+
+func TestPopFrontReclaimsCapacity(t *testing.T) {
+	v := atone.NewWithCapacity[*int](64)
+	capacity := v.Capacity()
+	for round := 0; round < 10; round++ {
+		for i := 0; i < capacity; i++ {
+			v.Push(new(int))
+		}
+		for !v.IsEmpty() {
+			v.PopFront()
+		}
+		// an empty Vec starts again from the front of its array, so it never grows
+		assert(v.Capacity() == capacity)
+	}
+}
+
+func TestQueueReclaimsFront(t *testing.T) {
+	counting := atone.NewCountingAllocator[*int](nil)
+	v := atone.NewWithAllocator[*int](counting)
+	v.Reserve(64)
+	allocs := counting.Allocs()
+	for i := 0; i < 10; i++ {
+		v.Push(new(int))
+	}
+	// the Vec is never empty, the front is reclaimed once it's more than half of the array
+	for i := 0; i < 10000; i++ {
+		v.Push(new(int))
+		v.PopFront()
+	}
+	assert(v.Len() == 10 && counting.Allocs() == allocs)
+}
+
+func TestFromSliceIsNotZeroed(t *testing.T) {
+	elements := []*int{new(int), new(int)}
+	v := atone.From(elements)
+	for i := 0; i < 100; i++ {
+		v.Push(new(int))
+	}
+	v.PopFront()
+	v.Clear()
+	v.Free()
+	// the slice belongs to the caller, the Vec never zeroes it
+	assert(elements[0] != nil && elements[1] != nil)
+}
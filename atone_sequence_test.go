@@ -0,0 +1,88 @@
+package test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+func TestSequence(t *testing.T) {
+	nItems := 50
+	slice := make([]int, 0)
+	sequences := []atone.Sequence[int]{atone.New[int](), atone.NewSync[int](), (*atone.SliceSeq[int])(&slice)}
+	for _, seq := range sequences {
+		for i := 0; i < nItems; i++ {
+			seq.Push(i)
+		}
+		seq.Set(0, -1)
+		assert(seq.Get(0) == -1 && seq.Len() == nItems)
+		assert(atone.IndexFunc(seq, func(el int) bool { return el == 30 }) == 30)
+		assert(!atone.ContainsFunc(seq, func(el int) bool { return el == nItems }))
+		assert(seq.PopBack() == nItems-1)
+		seq.All()(func(i int, el int) bool {
+			assert(i == 0 || el == i)
+			return true
+		})
+	}
+	assert(atone.Equal[int](sequences[0], sequences[1]))
+	assert(atone.Equal[int](sequences[0], sequences[2]))
+	assert(len(slice) == nItems-1)
+
+	atone.Reverse(sequences[0])
+	assert(sequences[0].Get(0) == nItems-2)
+	assert(!atone.Equal[int](sequences[0], sequences[2]))
+	copied := atone.New[int]()
+	atone.Extend[int](copied, sequences[0])
+	assert(atone.Equal[int](copied, sequences[0]))
+	assert(len(atone.Collect[int](copied)) == nItems-1)
+}
+
+func TestSequenceSameSource(t *testing.T) {
+	slice := []int{1, 2, 3}
+	sequences := []atone.Sequence[int]{atone.From([]int{1, 2, 3}), atone.NewSync[int](), (*atone.SliceSeq[int])(&slice)}
+	sequences[1].Push(1)
+	sequences[1].Push(2)
+	sequences[1].Push(3)
+	for _, seq := range sequences {
+		assert(atone.Equal(seq, seq))
+		atone.Extend(seq, seq)
+		assert(seq.Len() == 6)
+		for i := 0; i < 6; i++ {
+			assert(seq.Get(i) == i%3+1)
+		}
+	}
+}
+
+func TestSequenceSyncVecsBothWays(t *testing.T) {
+	a, b := atone.NewSync[int](), atone.NewSync[int]()
+	for i := 0; i < 100; i++ {
+		a.Push(i)
+		b.Push(i)
+	}
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+	for _, pair := range [][2]*atone.SyncVec[int]{{a, b}, {b, a}} {
+		wg.Add(1)
+		go func(dst, src *atone.SyncVec[int]) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				atone.Extend[int](dst, src)
+				atone.Equal[int](dst, src)
+				for dst.Len() > 200 {
+					dst.PopBack()
+				}
+			}
+		}(pair[0], pair[1])
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Extend and Equal deadlocked")
+	}
+}
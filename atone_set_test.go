@@ -0,0 +1,48 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+func TestSet(t *testing.T) {
+	nItems := 1000
+	s := atone.NewSet[int]()
+	for i := 0; i < nItems; i++ {
+		assert(s.Add(i))
+		assert(!s.Add(i))
+	}
+	assert(s.Len() == nItems)
+	assert(s.Remove(0) && !s.Remove(0) && !s.Has(0) && s.Has(1))
+	assert(len(s.Iter()) == nItems-1)
+}
+
+func TestSetAlgebra(t *testing.T) {
+	a := atone.NewSet(1, 2, 3, 4)
+	b := atone.NewSet(3, 4, 5)
+	hasExactly := func(s *atone.Set[int], elements ...int) bool {
+		if s.Len() != len(elements) {
+			return false
+		}
+		for _, el := range elements {
+			if !s.Has(el) {
+				return false
+			}
+		}
+		return true
+	}
+	assert(hasExactly(a.Union(b), 1, 2, 3, 4, 5))
+	assert(hasExactly(a.Intersect(b), 3, 4))
+	assert(hasExactly(a.Difference(b), 1, 2))
+	assert(hasExactly(a.SymmetricDifference(b), 1, 2, 5))
+}
+
+func TestSetZeroValue(t *testing.T) {
+	var s atone.Set[string]
+	assert(!s.Has("a") && !s.Remove("a") && s.IsEmpty() && len(s.Iter()) == 0)
+	s.Clear()
+	assert(s.Add("a") && !s.Add("a") && s.Has("a"))
+	var other atone.Set[string]
+	assert(s.Union(&other).Len() == 1 && s.Intersect(&other).IsEmpty() && s.Difference(&other).Len() == 1)
+}
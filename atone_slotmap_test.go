@@ -0,0 +1,37 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+func TestSlotMap(t *testing.T) {
+	nItems := 1000
+	s := atone.NewSlotMap[string]()
+	handles := make([]atone.Handle, 0, nItems)
+	for i := 0; i < nItems; i++ {
+		handles = append(handles, s.Insert("el"))
+	}
+	assert(!s.Contains(atone.Handle{}))
+	removed, ok := s.Remove(handles[10])
+	assert(ok && removed == "el")
+	_, ok = s.Remove(handles[10])
+	assert(!ok)
+	reused := s.Insert("new")
+	assert(reused.Index == handles[10].Index && reused.Generation != handles[10].Generation)
+	_, ok = s.Get(handles[10])
+	assert(!ok)
+	el, ok := s.Get(reused)
+	assert(ok && el == "new")
+	*s.GetRef(reused) = "changed"
+	el, _ = s.Get(reused)
+	assert(el == "changed")
+	assert(s.Len() == nItems)
+	n := 0
+	s.ForEach(func(h atone.Handle, el string) {
+		assert(s.Contains(h))
+		n++
+	})
+	assert(n == nItems)
+}
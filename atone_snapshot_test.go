@@ -0,0 +1,71 @@
+package test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+func TestSnapshotIsImmutable(t *testing.T) {
+	nItems := 100
+	arr := atone.New[int]()
+	for i := 0; i < nItems; i++ {
+		arr.Push(i)
+	}
+	view := arr.Snapshot()
+	arr.Swap(0, nItems-1)
+	arr.PopBack()
+	arr.Push(-1)
+	*arr.GetRef(3) = -3
+	for i := nItems; i < 2*nItems; i++ {
+		arr.Push(i)
+	}
+	assert(view.Len() == nItems)
+	for i := 0; i < nItems; i++ {
+		assert(view.Get(i) == i)
+	}
+	assert(arr.Get(0) == nItems-1 && arr.Get(3) == -3 && arr.Get(nItems-1) == -1)
+	assert(arr.Snapshot().Len() == 2*nItems)
+	arr.Truncate(10)
+	arr.Clear()
+	assert(view.Len() == nItems && view.Last() == nItems-1)
+}
+
+func TestSnapshotConcurrentReaders(t *testing.T) {
+	nItems := 20000
+	arr := atone.New[int]()
+	arr.Snapshot()
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				view := arr.Snapshot()
+				before := view.Iter()
+				view.ForEach(func(el int, i int) { assert(before[i] == el) })
+			}
+		}()
+	}
+	for i := 0; i < nItems; i++ {
+		arr.Push(i)
+		if i%100 == 0 {
+			arr.PopBack()
+			arr.Push(i)
+			arr.Swap(0, i)
+			arr.Swap(0, i)
+		}
+	}
+	close(done)
+	wg.Wait()
+	for i := 0; i < nItems; i++ {
+		assert(arr.Get(i) == i)
+	}
+}
@@ -0,0 +1,154 @@
+package test
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+type sortRecord struct {
+	Key int32
+	Seq int32
+}
+
+func TestExternalSortFunc(t *testing.T) {
+	dir := t.TempDir()
+	v := atone.New[sortRecord]()
+	nItems := 5000
+	for i := 0; i < nItems; i++ {
+		v.Push(sortRecord{Key: int32(rand.Intn(100)), Seq: int32(i)})
+	}
+	// runs of 16 elements, so there are more than can be merged at once
+	err := atone.ExternalSortFunc[sortRecord](v, func(a, b sortRecord) int {
+		return int(a.Key - b.Key)
+	}, 16*8, dir, atone.FixedCodec[sortRecord]{})
+	assert(err == nil)
+	assert(v.Len() == nItems)
+	for i := 1; i < nItems; i++ {
+		a, b := v.Get(i-1), v.Get(i)
+		assert(a.Key < b.Key || a.Key == b.Key && a.Seq < b.Seq)
+	}
+	files, err := os.ReadDir(dir)
+	assert(err == nil && len(files) == 0)
+}
+
+func TestExternalSortFuncInMemory(t *testing.T) {
+	v := atone.From([]string{"c", "a", "b"})
+	err := atone.ExternalSortFunc[string](v, func(a, b string) int {
+		if a < b {
+			return -1
+		}
+		if a > b {
+			return 1
+		}
+		return 0
+	}, 1<<20, t.TempDir(), atone.GobCodec[string]{})
+	assert(err == nil)
+	assert(v.Len() == 3 && v.Get(0) == "a" && v.Get(1) == "b" && v.Get(2) == "c")
+}
+
+func TestExternalSortFuncTo(t *testing.T) {
+	dir := t.TempDir()
+	v := atone.New[string]()
+	for i := 999; i >= 0; i-- {
+		v.Push(strconv.Itoa(i))
+	}
+	cmp := func(a, b string) int {
+		n, _ := strconv.Atoi(a)
+		n2, _ := strconv.Atoi(b)
+		return n - n2
+	}
+	expected := 0
+	err := atone.ExternalSortFuncTo[string](v, cmp, 100*16, dir, atone.GobCodec[string]{}, func(el string) error {
+		assert(el == strconv.Itoa(expected))
+		expected++
+		return nil
+	})
+	assert(err == nil && expected == 1000)
+	// v is not changed
+	assert(v.Len() == 1000 && v.Get(0) == "999")
+
+	stop := errors.New("stop")
+	err = atone.ExternalSortFuncTo[string](v, cmp, 100*16, dir, atone.GobCodec[string]{}, func(el string) error {
+		if el == "10" {
+			return stop
+		}
+		return nil
+	})
+	assert(err == stop)
+	files, err := os.ReadDir(dir)
+	assert(err == nil && len(files) == 0)
+}
+
+// failingCodec fails to decode after decodes elements
+type failingCodec struct {
+	atone.FixedCodec[int64]
+	decodes *int
+}
+
+var errDecode = errors.New("decode")
+
+func (c failingCodec) Decode(src []byte) (int64, error) {
+	if *c.decodes == 0 {
+		return 0, errDecode
+	}
+	*c.decodes--
+	return c.FixedCodec.Decode(src)
+}
+
+func TestExternalSortFuncKeepsVecOnError(t *testing.T) {
+	dir := t.TempDir()
+	v := atone.New[int64]()
+	for i := 1000; i > 0; i-- {
+		v.Push(int64(i))
+	}
+	decodes := 500
+	err := atone.ExternalSortFunc[int64](v, func(a, b int64) int {
+		return int(a - b)
+	}, 100*8, dir, failingCodec{decodes: &decodes})
+	assert(errors.Is(err, errDecode))
+	assert(v.Len() == 1000)
+	for i := 0; i < 1000; i++ {
+		assert(v.Get(i) == int64(1000-i))
+	}
+	files, err := os.ReadDir(dir)
+	assert(err == nil && len(files) == 0)
+}
+
+// peakAllocator remembers the most elements the arrays of a Vec had room for at once
+type peakAllocator struct {
+	*atone.CountingAllocator[int64]
+	peak int
+}
+
+func (a *peakAllocator) Alloc(capacity int) []int64 {
+	elements := a.CountingAllocator.Alloc(capacity)
+	if a.InUse() > a.peak {
+		a.peak = a.InUse()
+	}
+	return elements
+}
+
+func TestExternalSortFuncKeepsBudget(t *testing.T) {
+	alloc := &peakAllocator{CountingAllocator: atone.NewCountingAllocator[int64](nil)}
+	v := atone.NewWithAllocator[int64](alloc)
+	nItems := 10000
+	for i := nItems; i > 0; i-- {
+		v.Push(int64(i))
+	}
+	inUse := alloc.InUse()
+	alloc.peak = 0
+	err := atone.ExternalSortFunc[int64](v, func(a, b int64) int {
+		return int(a - b)
+	}, 100*8, t.TempDir(), atone.FixedCodec[int64]{})
+	assert(err == nil && v.Len() == nItems)
+	for i := 0; i < nItems; i++ {
+		assert(v.Get(i) == int64(i+1))
+	}
+	// the arrays of v are given back before the sorted elements are read, there are never two copies of them
+	assert(alloc.peak <= inUse)
+}
@@ -0,0 +1,43 @@
+package test
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+func TestSortedVec(t *testing.T) {
+	nItems := 3000
+	r := rand.New(rand.NewSource(1))
+	s := atone.NewSortedVec(func(a, b int) bool { return a < b })
+	elements := make([]int, 0, nItems)
+	for i := 0; i < nItems; i++ {
+		el := r.Intn(nItems)
+		s.Insert(el)
+		elements = append(elements, el)
+	}
+	for i := 0; i < nItems/2; i++ {
+		el := elements[len(elements)-1]
+		elements = elements[:len(elements)-1]
+		assert(s.Remove(el))
+	}
+	sort.Ints(elements)
+	assert(s.Len() == len(elements))
+	for i, el := range elements {
+		assert(s.Select(i) == el)
+		assert(s.Contains(el))
+		assert(s.Rank(el) == sort.SearchInts(elements, el))
+	}
+	assert(!s.Contains(-1) && !s.Remove(-1) && !s.Remove(nItems))
+
+	n := 0
+	s.Range(100, 200, func(el int) bool {
+		assert(el >= 100 && el < 200)
+		n++
+		return true
+	})
+	assert(n == sort.SearchInts(elements, 200)-sort.SearchInts(elements, 100))
+	assert(s.PopFront() == elements[0] && s.PopBack() == elements[len(elements)-1])
+}
@@ -0,0 +1,130 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+func TestSpillVec(t *testing.T) {
+	dir := t.TempDir()
+	// room for a few pages of 8 byte elements
+	s := atone.NewSpillVec[uint64](4*1024*8, atone.FixedCodec[uint64]{}, dir)
+	nItems := 50000
+	for i := 0; i < nItems; i++ {
+		s.Push(uint64(i))
+	}
+	files, err := os.ReadDir(dir)
+	assert(err == nil && len(files) == 1)
+	assert(s.Len() == nItems)
+	for i := 0; i < nItems; i += 997 {
+		assert(s.Get(i) == uint64(i))
+	}
+	s.Set(5, 500)
+	s.Set(nItems-2000, 1)
+	// walking everything spills the changed pages and brings them back
+	sum := uint64(0)
+	s.ForEach(func(el uint64, index int) {
+		sum += el
+	})
+	want := uint64(nItems) * uint64(nItems-1) / 2
+	assert(sum == want-5+500-uint64(nItems-2000)+1)
+	assert(s.Get(5) == 500 && s.Get(nItems-2000) == 1)
+	for i := nItems - 1; i >= 1500; i-- {
+		el := s.PopBack()
+		assert(i == nItems-2000 && el == 1 || i != nItems-2000 && el == uint64(i))
+	}
+	assert(s.Len() == 1500 && s.Get(1499) == 1499)
+	s.Push(7)
+	assert(s.Get(1500) == 7)
+	assert(s.Close() == nil)
+	files, err = os.ReadDir(dir)
+	assert(err == nil && len(files) == 0)
+}
+
+func TestSpillVecGob(t *testing.T) {
+	s := atone.NewSpillVec[string](1, atone.GobCodec[string]{}, t.TempDir())
+	defer s.Close()
+	for i := 0; i < 5000; i++ {
+		s.Push(strconv.Itoa(i))
+	}
+	for i := 4999; i >= 0; i -= 3 {
+		_, ok := s.Lookup(i)
+		assert(ok && s.Get(i) == strconv.Itoa(i))
+	}
+	_, ok := s.Lookup(5000)
+	assert(!ok)
+}
+
+func TestSpillVecRewritesInPlace(t *testing.T) {
+	dir := t.TempDir()
+	s := atone.NewSpillVec[string](1, atone.GobCodec[string]{}, dir)
+	defer s.Close()
+	nItems := 8 * 1024
+	for i := 0; i < nItems; i++ {
+		s.Push(strconv.Itoa(i))
+	}
+	files, err := os.ReadDir(dir)
+	assert(err == nil && len(files) == 1)
+	path := filepath.Join(dir, files[0].Name())
+	info, err := os.Stat(path)
+	assert(err == nil)
+	size := info.Size()
+	// every change spills the page it's in again, some of them with longer elements
+	for round := 0; round < 10; round++ {
+		for i := round; i < nItems; i += 1024 {
+			s.Set(i, strconv.Itoa(i*round))
+		}
+	}
+	info, err = os.Stat(path)
+	assert(err == nil && info.Size() < 2*size)
+	for i := 0; i < nItems; i++ {
+		round := i % 1024
+		assert(round >= 10 && s.Get(i) == strconv.Itoa(i) || round < 10 && s.Get(i) == strconv.Itoa(i*round))
+	}
+	assert(s.Err() == nil)
+}
+
+// sharedCodec decodes []byte elements without copying them
+type sharedCodec struct{}
+
+func (sharedCodec) Encode(dst []byte, el []byte) ([]byte, error) {
+	return append(dst, el...), nil
+}
+
+func (sharedCodec) Decode(src []byte) ([]byte, error) {
+	return src, nil
+}
+
+func TestSpillVecCodecKeepsBytes(t *testing.T) {
+	s := atone.NewSpillVec[[]byte](1, sharedCodec{}, t.TempDir())
+	defer s.Close()
+	nItems := 10000
+	for i := 0; i < nItems; i++ {
+		s.Push([]byte(strconv.Itoa(i)))
+	}
+	elements := make([][]byte, nItems)
+	for i := 0; i < nItems; i++ {
+		elements[i] = s.Get(i)
+	}
+	for i := 0; i < nItems; i++ {
+		assert(string(elements[i]) == strconv.Itoa(i))
+	}
+}
+
+func TestSpillVecErr(t *testing.T) {
+	// the temp file can't be created, every page stays in memory
+	s := atone.NewSpillVec[uint64](1, atone.FixedCodec[uint64]{}, filepath.Join(t.TempDir(), "missing"))
+	for i := 0; i < 5000; i++ {
+		s.Push(uint64(i))
+	}
+	assert(s.Err() != nil)
+	for i := 0; i < 5000; i++ {
+		assert(s.Get(i) == uint64(i))
+	}
+	assert(s.PopBack() == 4999 && s.Len() == 4999)
+	assert(s.Close() != nil && s.Err() == nil)
+}
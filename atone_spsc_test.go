@@ -0,0 +1,64 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+func TestSPSCQueue(t *testing.T) {
+	nItems := 100000
+	q := atone.NewSPSCQueue[int](2)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < nItems; i++ {
+			q.Push(i)
+		}
+	}()
+	for expected := 0; expected < nItems; {
+		el, ok := q.Pop()
+		if !ok {
+			continue
+		}
+		assert(el == expected)
+		expected++
+	}
+	<-done
+	_, ok := q.Pop()
+	assert(!ok)
+	assert(q.IsEmpty())
+}
+
+func TestSPSCQueueGrowKeepsOrder(t *testing.T) {
+	q := atone.NewSPSCQueue[int](4)
+	for i := 0; i < 3; i++ {
+		q.Push(i)
+	}
+	first, _ := q.Pop()
+	assert(first == 0)
+	for i := 3; i < 20; i++ {
+		q.Push(i)
+	}
+	assert(q.Len() == 19)
+	peeked, _ := q.Peek()
+	assert(peeked == 1)
+	for i := 1; i < 20; i++ {
+		el, ok := q.Pop()
+		assert(ok && el == i)
+	}
+}
+
+func TestSPSCQueueNonPositiveCapacity(t *testing.T) {
+	for _, capacity := range []int{-5, 0} {
+		q := atone.NewSPSCQueue[int](capacity)
+		for i := 0; i < 10; i++ {
+			q.Push(i)
+		}
+		for i := 0; i < 10; i++ {
+			el, ok := q.Pop()
+			assert(ok && el == i)
+		}
+		assert(q.IsEmpty())
+	}
+}
@@ -0,0 +1,56 @@
+package test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/gabivlj/atone-go/atone"
+)
+
+func TestSyncVecConcurrentPush(t *testing.T) {
+	nGoroutines := 8
+	nItems := 1000
+	arr := atone.NewSync[int]()
+	wg := sync.WaitGroup{}
+	for g := 0; g < nGoroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < nItems; i++ {
+				arr.Push(i)
+				arr.Len()
+			}
+		}()
+	}
+	wg.Wait()
+	assert(arr.Len() == nGoroutines*nItems)
+	seen := make([]int, nItems)
+	arr.ForEach(func(el int, _ int) { seen[el]++ })
+	for i := range seen {
+		assert(seen[i] == nGoroutines)
+	}
+}
+
+func TestSyncVecWithLock(t *testing.T) {
+	arr := atone.NewSync[int]()
+	wg := sync.WaitGroup{}
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				// pairs must always end up next to each other
+				arr.WithLock(func(v *atone.Vec[int]) {
+					v.Push(i)
+					v.Push(i)
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	elements := arr.Array()
+	assert(len(elements) == 800)
+	for i := 0; i < len(elements); i += 2 {
+		assert(elements[i] == elements[i+1])
+	}
+}
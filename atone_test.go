@@ -248,6 +248,15 @@ func BenchmarkInsert(b *testing.B) {
 	}
 }
 
+func BenchmarkPushWhileCarrying(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		arr := atone.New[int]()
+		for j := 0; j < 1<<16; j++ {
+			arr.Push(j)
+		}
+	}
+}
+
 func TestReverse(t *testing.T) {
 	nItems := 26
 	arr := atone.New[int]()
@@ -301,6 +310,83 @@ func TestSlice(t *testing.T) {
 	}
 }
 
+// pushedVec returns a Vec with the elements 0..n-1 pushed one by one, so depending on n part of them are still
+// in the oldHead, and the same elements in a slice
+func pushedVec(n int) (*atone.Vec[int], []int) {
+	arr := atone.New[int]()
+	expected := make([]int, n)
+	for i := 0; i < n; i++ {
+		arr.Push(i)
+		expected[i] = i
+	}
+	return arr, expected
+}
+
+func assertVecEquals(arr *atone.Vec[int], expected []int) {
+	assert(arr.Len() == len(expected))
+	for i := range expected {
+		assert(arr.Get(i) == expected[i])
+	}
+}
+
+func TestSwapAcrossSegments(t *testing.T) {
+	for n := 2; n < 80; n++ {
+		for _, pair := range [][2]int{{0, n - 1}, {n - 1, 0}, {n / 2, n - 1}, {n - 1, n / 2}, {0, n / 2}} {
+			arr, expected := pushedVec(n)
+			arr.Swap(pair[0], pair[1])
+			expected[pair[0]], expected[pair[1]] = expected[pair[1]], expected[pair[0]]
+			assertVecEquals(arr, expected)
+		}
+	}
+}
+
+func TestTruncateKeepsFirstElements(t *testing.T) {
+	for n := 0; n < 80; n++ {
+		for keep := 0; keep <= n; keep++ {
+			arr, expected := pushedVec(n)
+			arr.Truncate(keep)
+			assertVecEquals(arr, expected[:keep])
+			arr.Push(-1)
+			assertVecEquals(arr, append(expected[:keep], -1))
+		}
+	}
+}
+
+func TestLastAndSliceAcrossSegments(t *testing.T) {
+	for n := 1; n < 80; n++ {
+		arr, expected := pushedVec(n)
+		assert(arr.Last() == n-1)
+		for start := 0; start <= n; start++ {
+			for _, end := range []int{start, (start + n) / 2, n} {
+				elements := arr.Slice(start, end)
+				assert(len(elements) == end-start)
+				for i := range elements {
+					assert(elements[i] == expected[start+i])
+				}
+			}
+		}
+		// PopBack empties the newTail first, then Last reads the oldHead
+		for len(expected) > 1 {
+			arr.PopBack()
+			expected = expected[:len(expected)-1]
+			assert(arr.Last() == expected[len(expected)-1])
+		}
+	}
+}
+
+func TestForEachIndexes(t *testing.T) {
+	for n := 0; n < 80; n++ {
+		arr, _ := pushedVec(n)
+		seen := 0
+		// the index is the position in the whole Vec, not in the segment that has the element
+		arr.ForEach(func(el int, index int) {
+			assert(el == index && index == seen)
+			seen++
+		})
+		assert(seen == n)
+	}
+}
+
 func assert(cond bool) {
 	if !cond {
 		panic("condition not met")